@@ -0,0 +1,77 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReadyToEnforceCondition is True when enforcing the Policy would not break any workload
+const ReadyToEnforceCondition = "ReadyToEnforce"
+
+// EnforcementReadinessSpec is empty, the readiness is entirely maintained by the exception-recommender
+type EnforcementReadinessSpec struct {
+}
+
+// EnforcementReadinessStatus tells whether the Policy of the PolicyManifest of the same name can be enforced
+type EnforcementReadinessStatus struct {
+	// Mode of the PolicyManifest
+	Mode string `json:"mode,omitempty"`
+	// OutstandingFailures is the number of workloads failing the Policy
+	OutstandingFailures int32 `json:"outstandingFailures"`
+	// CoveredFailures is the number of failing workloads with an AutomatedException for the Policy
+	CoveredFailures int32 `json:"coveredFailures"`
+	// UncoveredWorkloads lists the failing workloads without AutomatedException, as namespace/kind/name
+	// +optional
+	UncoveredWorkloads []string `json:"uncoveredWorkloads,omitempty"`
+	// Conditions holds the ReadyToEnforce condition
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster,shortName=enfready
+//+kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.status.mode`
+//+kubebuilder:printcolumn:name="Failures",type=integer,JSONPath=`.status.outstandingFailures`
+//+kubebuilder:printcolumn:name="Covered",type=integer,JSONPath=`.status.coveredFailures`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="ReadyToEnforce")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// EnforcementReadiness is the Schema for the enforcementreadinesses API
+type EnforcementReadiness struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EnforcementReadinessSpec   `json:"spec,omitempty"`
+	Status EnforcementReadinessStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EnforcementReadinessList contains a list of EnforcementReadiness
+type EnforcementReadinessList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnforcementReadiness `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnforcementReadiness{}, &EnforcementReadinessList{})
+}
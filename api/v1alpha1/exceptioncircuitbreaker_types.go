@@ -0,0 +1,77 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CreationPausedCondition is True while the creation of AutomatedExceptions is paused
+	CreationPausedCondition = "CreationPaused"
+	// ResumeAnnotation closes an open circuit breaker when set to "true"
+	ResumeAnnotation = "policy.giantswarm.io/resume"
+)
+
+// ExceptionCircuitBreakerSpec is empty, the circuit breaker is opened by the exception-recommender
+// and closed with the ResumeAnnotation
+type ExceptionCircuitBreakerSpec struct {
+}
+
+// ExceptionCircuitBreakerStatus is the state of the circuit breaker guarding the creation of AutomatedExceptions
+type ExceptionCircuitBreakerStatus struct {
+	// Open is true while the creation of AutomatedExceptions is paused
+	Open bool `json:"open"`
+	// TrippedAt is when the circuit breaker last opened
+	// +optional
+	TrippedAt *metav1.Time `json:"trippedAt,omitempty"`
+	// Conditions holds the CreationPaused condition
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster,shortName=excb
+//+kubebuilder:printcolumn:name="Open",type=boolean,JSONPath=`.status.open`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="CreationPaused")].reason`
+//+kubebuilder:printcolumn:name="Tripped",type=date,JSONPath=`.status.trippedAt`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ExceptionCircuitBreaker is the Schema for the exceptioncircuitbreakers API
+type ExceptionCircuitBreaker struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ExceptionCircuitBreakerSpec   `json:"spec,omitempty"`
+	Status ExceptionCircuitBreakerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ExceptionCircuitBreakerList contains a list of ExceptionCircuitBreaker
+type ExceptionCircuitBreakerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExceptionCircuitBreaker `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExceptionCircuitBreaker{}, &ExceptionCircuitBreakerList{})
+}
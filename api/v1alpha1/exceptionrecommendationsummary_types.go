@@ -0,0 +1,98 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExceptionRecommendationSummarySpec is empty, the summary is entirely maintained by the exception-recommender
+type ExceptionRecommendationSummarySpec struct {
+}
+
+// ExceptionRecommendationSummaryStatus aggregates the recommendations for the workloads of a namespace
+type ExceptionRecommendationSummaryStatus struct {
+	// Workloads is the number of target workloads with a PolicyReport
+	Workloads int32 `json:"workloads"`
+	// FailingWorkloads is the number of target workloads failing at least one Policy of the target categories
+	FailingWorkloads int32 `json:"failingWorkloads"`
+	// CompliancePercent is the share of target workloads without failures, rounded down
+	CompliancePercent int32 `json:"compliancePercent"`
+	// Policies counts the failing workloads per Policy
+	// +optional
+	Policies []PolicySummary `json:"policies,omitempty"`
+	// Categories counts the failing workloads per Policy category
+	// +optional
+	Categories []CategorySummary `json:"categories,omitempty"`
+	// DraftedExceptions lists the AutomatedExceptions drafted for the workloads
+	// +optional
+	DraftedExceptions []DraftedException `json:"draftedExceptions,omitempty"`
+}
+
+// PolicySummary is the number of failing workloads for a Policy
+type PolicySummary struct {
+	Policy           string `json:"policy"`
+	Category         string `json:"category,omitempty"`
+	FailingWorkloads int32  `json:"failingWorkloads"`
+}
+
+// CategorySummary is the number of failing workloads for a Policy category
+type CategorySummary struct {
+	Category         string `json:"category"`
+	FailingWorkloads int32  `json:"failingWorkloads"`
+}
+
+// DraftedException references an AutomatedException drafted for a workload of the namespace
+type DraftedException struct {
+	// Name and Namespace of the AutomatedException
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Kind and WorkloadName of the excepted workload
+	Kind         string   `json:"kind"`
+	WorkloadName string   `json:"workloadName"`
+	Policies     []string `json:"policies,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=exrecsum
+//+kubebuilder:printcolumn:name="Workloads",type=integer,JSONPath=`.status.workloads`
+//+kubebuilder:printcolumn:name="Failing",type=integer,JSONPath=`.status.failingWorkloads`
+//+kubebuilder:printcolumn:name="Compliance",type=integer,JSONPath=`.status.compliancePercent`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ExceptionRecommendationSummary is the Schema for the exceptionrecommendationsummaries API
+type ExceptionRecommendationSummary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ExceptionRecommendationSummarySpec   `json:"spec,omitempty"`
+	Status ExceptionRecommendationSummaryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ExceptionRecommendationSummaryList contains a list of ExceptionRecommendationSummary
+type ExceptionRecommendationSummaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExceptionRecommendationSummary `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExceptionRecommendationSummary{}, &ExceptionRecommendationSummaryList{})
+}
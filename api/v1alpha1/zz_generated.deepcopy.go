@@ -0,0 +1,383 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CategorySummary) DeepCopyInto(out *CategorySummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CategorySummary.
+func (in *CategorySummary) DeepCopy() *CategorySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CategorySummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DraftedException) DeepCopyInto(out *DraftedException) {
+	*out = *in
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DraftedException.
+func (in *DraftedException) DeepCopy() *DraftedException {
+	if in == nil {
+		return nil
+	}
+	out := new(DraftedException)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnforcementReadiness) DeepCopyInto(out *EnforcementReadiness) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnforcementReadiness.
+func (in *EnforcementReadiness) DeepCopy() *EnforcementReadiness {
+	if in == nil {
+		return nil
+	}
+	out := new(EnforcementReadiness)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnforcementReadiness) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnforcementReadinessList) DeepCopyInto(out *EnforcementReadinessList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EnforcementReadiness, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnforcementReadinessList.
+func (in *EnforcementReadinessList) DeepCopy() *EnforcementReadinessList {
+	if in == nil {
+		return nil
+	}
+	out := new(EnforcementReadinessList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnforcementReadinessList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnforcementReadinessSpec) DeepCopyInto(out *EnforcementReadinessSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnforcementReadinessSpec.
+func (in *EnforcementReadinessSpec) DeepCopy() *EnforcementReadinessSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnforcementReadinessSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnforcementReadinessStatus) DeepCopyInto(out *EnforcementReadinessStatus) {
+	*out = *in
+	if in.UncoveredWorkloads != nil {
+		in, out := &in.UncoveredWorkloads, &out.UncoveredWorkloads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnforcementReadinessStatus.
+func (in *EnforcementReadinessStatus) DeepCopy() *EnforcementReadinessStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnforcementReadinessStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionCircuitBreaker) DeepCopyInto(out *ExceptionCircuitBreaker) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionCircuitBreaker.
+func (in *ExceptionCircuitBreaker) DeepCopy() *ExceptionCircuitBreaker {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionCircuitBreaker)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExceptionCircuitBreaker) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionCircuitBreakerList) DeepCopyInto(out *ExceptionCircuitBreakerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExceptionCircuitBreaker, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionCircuitBreakerList.
+func (in *ExceptionCircuitBreakerList) DeepCopy() *ExceptionCircuitBreakerList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionCircuitBreakerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExceptionCircuitBreakerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionCircuitBreakerSpec) DeepCopyInto(out *ExceptionCircuitBreakerSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionCircuitBreakerSpec.
+func (in *ExceptionCircuitBreakerSpec) DeepCopy() *ExceptionCircuitBreakerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionCircuitBreakerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionCircuitBreakerStatus) DeepCopyInto(out *ExceptionCircuitBreakerStatus) {
+	*out = *in
+	if in.TrippedAt != nil {
+		in, out := &in.TrippedAt, &out.TrippedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionCircuitBreakerStatus.
+func (in *ExceptionCircuitBreakerStatus) DeepCopy() *ExceptionCircuitBreakerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionCircuitBreakerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionRecommendationSummary) DeepCopyInto(out *ExceptionRecommendationSummary) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionRecommendationSummary.
+func (in *ExceptionRecommendationSummary) DeepCopy() *ExceptionRecommendationSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionRecommendationSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExceptionRecommendationSummary) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionRecommendationSummaryList) DeepCopyInto(out *ExceptionRecommendationSummaryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExceptionRecommendationSummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionRecommendationSummaryList.
+func (in *ExceptionRecommendationSummaryList) DeepCopy() *ExceptionRecommendationSummaryList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionRecommendationSummaryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExceptionRecommendationSummaryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionRecommendationSummarySpec) DeepCopyInto(out *ExceptionRecommendationSummarySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionRecommendationSummarySpec.
+func (in *ExceptionRecommendationSummarySpec) DeepCopy() *ExceptionRecommendationSummarySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionRecommendationSummarySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionRecommendationSummaryStatus) DeepCopyInto(out *ExceptionRecommendationSummaryStatus) {
+	*out = *in
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]PolicySummary, len(*in))
+		copy(*out, *in)
+	}
+	if in.Categories != nil {
+		in, out := &in.Categories, &out.Categories
+		*out = make([]CategorySummary, len(*in))
+		copy(*out, *in)
+	}
+	if in.DraftedExceptions != nil {
+		in, out := &in.DraftedExceptions, &out.DraftedExceptions
+		*out = make([]DraftedException, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionRecommendationSummaryStatus.
+func (in *ExceptionRecommendationSummaryStatus) DeepCopy() *ExceptionRecommendationSummaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionRecommendationSummaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicySummary) DeepCopyInto(out *PolicySummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicySummary.
+func (in *PolicySummary) DeepCopy() *PolicySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicySummary)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Reasons of the recorded operations
+const (
+	// ReasonFailing is the reason of AutomatedExceptions created or updated for failing results
+	ReasonFailing = "failing"
+	// ReasonClean is the reason of AutomatedExceptions deleted because the results of their workload are clean
+	ReasonClean = "clean"
+	// ReasonExpired is the reason of AutomatedExceptions marked, and revoked with the delete action, once expired
+	ReasonExpired = "expired"
+	// ReasonOrphaned is the reason of AutomatedExceptions deleted along with their workload
+	ReasonOrphaned = "orphaned"
+	// ReasonDrift is the reason of AutomatedExceptions restored after being deleted or modified by others
+	ReasonDrift = "drift"
+	// ReasonPromoted is the reason of PolicyExceptions created or updated from approved AutomatedExceptions,
+	// and of the AutomatedExceptions marked as promoted
+	ReasonPromoted = "promoted"
+	// ReasonStale is the reason of approved AutomatedExceptions whose approval was cleared since their workload
+	// no longer fails all their policies
+	ReasonStale = "stale"
+	// ReasonRenewed is the reason of AutomatedExceptions renewed on request
+	ReasonRenewed = "renewed"
+	// ReasonRenewalRejected is the reason of AutomatedExceptions whose renewal request was rejected
+	ReasonRenewalRejected = "renewal-rejected"
+	// ReasonGracePeriod is the reason of AutomatedExceptions whose deletion is delayed by the grace period
+	ReasonGracePeriod = "grace-period"
+)
+
+// Record is an entry of the audit trail, describing why the recommender changed an AutomatedException.
+type Record struct {
+	Time time.Time `json:"time"`
+	// Controller which took the decision
+	Controller string `json:"controller"`
+	// Operation is one of created, updated or deleted
+	Operation          string   `json:"operation"`
+	Reason             string   `json:"reason"`
+	AutomatedException Object   `json:"automatedException"`
+	Policies           []string `json:"policies,omitempty"`
+	Workload           *Object  `json:"workload,omitempty"`
+	// PolicyReport the decision was taken from, along with its failing results
+	PolicyReport   *Object  `json:"policyReport,omitempty"`
+	FailingResults []Result `json:"failingResults,omitempty"`
+	// ManifestModes is the mode of the PolicyManifest of every failing Policy at the time of the decision,
+	// empty if the PolicyManifest was missing
+	ManifestModes map[string]string `json:"manifestModes,omitempty"`
+	// PolicyException the AutomatedException was promoted into, the operation and diff then apply to it
+	PolicyException *Object `json:"policyException,omitempty"`
+	// Diff between the AutomatedException before and after the operation
+	Diff string `json:"diff,omitempty"`
+}
+
+// Object references a Kubernetes object at a given resourceVersion.
+type Object struct {
+	Kind            string `json:"kind,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name"`
+	UID             string `json:"uid,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// Result is a failing result of a PolicyReport.
+type Result struct {
+	Policy   string `json:"policy"`
+	Rule     string `json:"rule,omitempty"`
+	Category string `json:"category,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Sink stores the audit records, it must never rewrite the records it has stored nor drop them before its retention.
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+}
+
+// Trail writes every record to all its sinks.
+type Trail struct {
+	Sinks map[string]Sink
+}
+
+var FailuresMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "exception_recommender_audit_failures_total",
+		Help: "Number of audit records which could not be written by sink",
+	}, []string{"sink"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(FailuresMetric)
+}
+
+// Write stamps the record and writes it to every sink, a failing sink doesn't prevent the others from being written.
+func (t *Trail) Write(ctx context.Context, record Record) error {
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+
+	var errs []error
+	for name, sink := range t.Sinks {
+		if err := sink.Write(ctx, record); err != nil {
+			FailuresMetric.WithLabelValues(name).Inc()
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
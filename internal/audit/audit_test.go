@@ -0,0 +1,196 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+type failingSink struct{}
+
+func (failingSink) Write(context.Context, Record) error {
+	return errors.New("unavailable")
+}
+
+var _ = Describe("Audit", func() {
+	ctx := context.Background()
+	day := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	record := func(name string) Record {
+		return Record{
+			Time:               day,
+			Controller:         "policyreport",
+			Operation:          "created",
+			Reason:             ReasonFailing,
+			AutomatedException: Object{Namespace: "policy-exceptions", Name: name},
+			Policies:           []string{"require-labels"},
+		}
+	}
+	decode := func(data string) []Record {
+		var records []Record
+		for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+			var record Record
+			Expect(json.Unmarshal([]byte(line), &record)).To(Succeed())
+			records = append(records, record)
+		}
+		return records
+	}
+
+	It("writes JSON lines", func() {
+		var buffer bytes.Buffer
+		sink := &WriterSink{Writer: &buffer}
+
+		Expect(sink.Write(ctx, record("api"))).To(Succeed())
+		Expect(sink.Write(ctx, record("web"))).To(Succeed())
+
+		Expect(decode(buffer.String())).To(Equal([]Record{record("api"), record("web")}))
+	})
+
+	It("appends to a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "audit.jsonl")
+
+		Expect((&FileSink{Path: path}).Write(ctx, record("api"))).To(Succeed())
+		Expect((&FileSink{Path: path}).Write(ctx, record("web"))).To(Succeed())
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decode(string(data))).To(Equal([]Record{record("api"), record("web")}))
+	})
+
+	It("appends to daily ConfigMaps, continuing in a new one when full", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		sink := &ConfigMapSink{Client: c, Namespace: "security", Prefix: "exception-recommender-audit"}
+
+		Expect(sink.Write(ctx, record("api"))).To(Succeed())
+		Expect(sink.Write(ctx, record("web"))).To(Succeed())
+		nextDay := record("worker")
+		nextDay.Time = day.Add(24 * time.Hour)
+		Expect(sink.Write(ctx, nextDay)).To(Succeed())
+		Expect(sink.Flush(ctx)).To(Succeed())
+
+		var configMap corev1.ConfigMap
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "security", Name: "exception-recommender-audit-2026-03-01"}, &configMap)).To(Succeed())
+		Expect(configMap.Labels).To(HaveKeyWithValue(TrailLabel, "exception-recommender-audit"))
+		Expect(decode(configMap.Data[ConfigMapKey])).To(Equal([]Record{record("api"), record("web")}))
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "security", Name: "exception-recommender-audit-2026-03-02"}, &configMap)).To(Succeed())
+		Expect(decode(configMap.Data[ConfigMapKey])).To(Equal([]Record{nextDay}))
+
+		// Fill the ConfigMap of the day
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "security", Name: "exception-recommender-audit-2026-03-01"}, &configMap)).To(Succeed())
+		configMap.Data[ConfigMapKey] += strings.Repeat("x", maxConfigMapBytes)
+		Expect(c.Update(ctx, &configMap)).To(Succeed())
+
+		Expect(sink.Write(ctx, record("db"))).To(Succeed())
+		Expect(sink.Flush(ctx)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "security", Name: "exception-recommender-audit-2026-03-01-1"}, &configMap)).To(Succeed())
+		Expect(decode(configMap.Data[ConfigMapKey])).To(Equal([]Record{record("db")}))
+	})
+
+	It("prunes the ConfigMaps past the retention when a new day starts", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		sink := &ConfigMapSink{Client: c, Namespace: "security", Prefix: "exception-recommender-audit", MaxDays: 2}
+		other := &ConfigMapSink{Client: c, Namespace: "security", Prefix: "other-audit"}
+
+		for offset := range 3 {
+			dayRecord := record("api")
+			dayRecord.Time = day.AddDate(0, 0, offset)
+			Expect(sink.Write(ctx, dayRecord)).To(Succeed())
+			Expect(sink.Flush(ctx)).To(Succeed())
+			Expect(other.Write(ctx, dayRecord)).To(Succeed())
+			Expect(other.Flush(ctx)).To(Succeed())
+		}
+		// Continued part of the first day
+		var configMap corev1.ConfigMap
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "security", Name: "exception-recommender-audit-2026-03-01"}, &configMap)).To(Succeed())
+		configMap.Data[ConfigMapKey] += strings.Repeat("x", maxConfigMapBytes)
+		Expect(c.Update(ctx, &configMap)).To(Succeed())
+		Expect(sink.Write(ctx, record("db"))).To(Succeed())
+
+		nextDay := record("web")
+		nextDay.Time = day.AddDate(0, 0, 3)
+		Expect(sink.Write(ctx, nextDay)).To(Succeed())
+		Expect(sink.Flush(ctx)).To(Succeed())
+
+		var configMaps corev1.ConfigMapList
+		Expect(c.List(ctx, &configMaps, client.InNamespace("security"))).To(Succeed())
+		var names []string
+		for _, item := range configMaps.Items {
+			names = append(names, item.Name)
+		}
+		Expect(names).To(ConsistOf(
+			"exception-recommender-audit-2026-03-02",
+			"exception-recommender-audit-2026-03-03",
+			"exception-recommender-audit-2026-03-04",
+			"other-audit-2026-03-01",
+			"other-audit-2026-03-02",
+			"other-audit-2026-03-03",
+		))
+	})
+
+	It("appends the records in one update and keeps them when it fails", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		updates := 0
+		failing := true
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				updates++
+				if failing {
+					return errors.New("unavailable")
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}).Build()
+		sink := &ConfigMapSink{Client: c, Namespace: "security", Prefix: "exception-recommender-audit"}
+
+		Expect(sink.Write(ctx, record("api"))).To(Succeed())
+		Expect(sink.Flush(ctx)).To(Succeed())
+		Expect(sink.Write(ctx, record("web"))).To(Succeed())
+		Expect(sink.Write(ctx, record("db"))).To(Succeed())
+		Expect(sink.Flush(ctx)).To(MatchError(ContainSubstring("unavailable")))
+
+		failing = false
+		updates = 0
+		Expect(sink.Write(ctx, record("worker"))).To(Succeed())
+		Expect(sink.Flush(ctx)).To(Succeed())
+		Expect(updates).To(Equal(1))
+
+		var configMap corev1.ConfigMap
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "security", Name: "exception-recommender-audit-2026-03-01"}, &configMap)).To(Succeed())
+		Expect(decode(configMap.Data[ConfigMapKey])).To(Equal([]Record{record("api"), record("web"), record("db"), record("worker")}))
+	})
+
+	It("writes to every sink and counts the failures", func() {
+		var buffer bytes.Buffer
+		trail := &Trail{Sinks: map[string]Sink{"stdout": &WriterSink{Writer: &buffer}, "broken": failingSink{}}}
+		failures := testutil.ToFloat64(FailuresMetric.WithLabelValues("broken"))
+
+		unstamped := record("api")
+		unstamped.Time = time.Time{}
+		Expect(trail.Write(ctx, unstamped)).To(MatchError(ContainSubstring("unavailable")))
+
+		records := decode(buffer.String())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Time).NotTo(BeZero())
+		Expect(testutil.ToFloat64(FailuresMetric.WithLabelValues("broken"))).To(Equal(failures + 1))
+	})
+})
@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+const (
+	// ConfigMapKey holds the records as JSON lines
+	ConfigMapKey = "records.jsonl"
+	// TrailLabel selects the ConfigMaps of an audit trail, its value is the Prefix of the ConfigMapSink
+	TrailLabel = "policy.giantswarm.io/audit-trail"
+	// maxConfigMapBytes keeps the ConfigMaps below the 1MiB object size limit
+	maxConfigMapBytes = 900 * 1024
+)
+
+// DefaultFlushInterval is how often the ConfigMapSink appends the buffered records
+const DefaultFlushInterval = 10 * time.Second
+
+// ConfigMapSink appends the records as JSON lines to one ConfigMap per UTC day, named after the Prefix and the date.
+// Once a ConfigMap grows close to the object size limit, the records of the day continue in a numbered ConfigMap.
+// Records are never rewritten, the ConfigMaps of the days past the retention are deleted when a new day starts.
+// Write only buffers the records, Start appends them in batches from a single goroutine so that the reconcilers
+// neither wait for the API server nor conflict with each other. Records which can't be appended are kept for the
+// next batch.
+type ConfigMapSink struct {
+	Client    client.Client
+	Namespace string
+	Prefix    string
+	// MaxDays is the number of days the ConfigMaps are kept, they must be deleted by the cluster operator when zero
+	MaxDays int
+	// FlushInterval is how often the buffered records are appended, DefaultFlushInterval when zero
+	FlushInterval time.Duration
+	Log           logr.Logger
+
+	mu      sync.Mutex
+	pending []line
+}
+
+// line is a record buffered by the ConfigMapSink
+type line struct {
+	day  string
+	data string
+}
+
+func (s *ConfigMapSink) Write(_ context.Context, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, line{day: record.Time.UTC().Format("2006-01-02"), data: string(data) + "\n"})
+	return nil
+}
+
+// Start appends the buffered records every FlushInterval, and the remaining ones once the context is done.
+func (s *ConfigMapSink) Start(ctx context.Context) error {
+	interval := s.FlushInterval
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), interval)
+			defer cancel()
+			return s.Flush(flushCtx)
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.Log.Error(err, "unable to append the audit records, retrying with the next batch")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection makes every replica append its own records.
+func (s *ConfigMapSink) NeedLeaderElection() bool {
+	return false
+}
+
+// Flush appends the buffered records to the ConfigMaps of their day, the records not appended are kept.
+func (s *ConfigMapSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	appended := 0
+	var err error
+	var newDay string
+	for appended < len(pending) && err == nil {
+		// The records are appended in batches of the same day
+		day := pending[appended].day
+		var lines []string
+		for _, line := range pending[appended:] {
+			if line.day != day {
+				break
+			}
+			lines = append(lines, line.data)
+		}
+
+		var written int
+		var created bool
+		written, created, err = s.append(ctx, day, lines)
+		appended += written
+		if created {
+			newDay = day
+		}
+	}
+	if err != nil {
+		FailuresMetric.WithLabelValues("configmap").Inc()
+		s.mu.Lock()
+		s.pending = append(pending[appended:], s.pending...)
+		s.mu.Unlock()
+		return err
+	}
+	if newDay == "" || s.MaxDays <= 0 {
+		return nil
+	}
+
+	day, _ := time.Parse("2006-01-02", newDay)
+	return s.prune(ctx, day.AddDate(0, 0, -s.MaxDays).Format("2006-01-02"))
+}
+
+// append appends the lines to the ConfigMaps of the day, and returns how many were written and whether the first
+// ConfigMap of the day was created.
+func (s *ConfigMapSink) append(ctx context.Context, day string, lines []string) (int, bool, error) {
+	written := 0
+	created := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		for part := 0; written < len(lines); part++ {
+			name := fmt.Sprintf("%s-%s", s.Prefix, day)
+			if part > 0 {
+				name = fmt.Sprintf("%s-%d", name, part)
+			}
+
+			var configMap corev1.ConfigMap
+			err := s.Client.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: name}, &configMap)
+			switch {
+			case apierrors.IsNotFound(err):
+				data, n := fill("", lines[written:])
+				configMap = corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: s.Namespace,
+						Labels:    map[string]string{utils.AppLabelName: utils.ComponentName, TrailLabel: s.Prefix},
+					},
+					Data: map[string]string{ConfigMapKey: data},
+				}
+				if err := s.Client.Create(ctx, &configMap); err != nil {
+					if apierrors.IsAlreadyExists(err) {
+						// Created concurrently, retry as a conflict
+						return apierrors.NewConflict(corev1.Resource("configmaps"), name, err)
+					}
+					return err
+				}
+				created = created || part == 0
+				written += n
+				continue
+			case err != nil:
+				return err
+			}
+
+			data, n := fill(configMap.Data[ConfigMapKey], lines[written:])
+			if n == 0 {
+				// Full, continue in the next part
+				continue
+			}
+			if configMap.Data == nil {
+				configMap.Data = make(map[string]string)
+			}
+			configMap.Data[ConfigMapKey] = data
+			if err := s.Client.Update(ctx, &configMap); err != nil {
+				return err
+			}
+			written += n
+		}
+		return nil
+	})
+	return written, created, err
+}
+
+// fill appends the lines fitting below maxConfigMapBytes to the data and returns how many were appended,
+// at least one to empty data.
+func fill(data string, lines []string) (string, int) {
+	var builder strings.Builder
+	builder.WriteString(data)
+	n := 0
+	for _, next := range lines {
+		if builder.Len() > 0 && builder.Len()+len(next) > maxConfigMapBytes {
+			break
+		}
+		builder.WriteString(next)
+		n++
+	}
+	return builder.String(), n
+}
+
+// prune deletes the ConfigMaps of the trail of the days before the given one.
+func (s *ConfigMapSink) prune(ctx context.Context, before string) error {
+	var configMaps corev1.ConfigMapList
+	if err := s.Client.List(ctx, &configMaps, client.InNamespace(s.Namespace), client.MatchingLabels{TrailLabel: s.Prefix}); err != nil {
+		return fmt.Errorf("unable to prune the audit ConfigMaps: %w", err)
+	}
+
+	for i := range configMaps.Items {
+		// Days are formatted as 2006-01-02 which sorts lexically, parts are suffixed after the day
+		day, found := strings.CutPrefix(configMaps.Items[i].Name, s.Prefix+"-")
+		if !found || len(day) < len(before) || day[:len(before)] >= before {
+			continue
+		}
+		if err := s.Client.Delete(ctx, &configMaps.Items[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to prune the audit ConfigMaps: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterSink writes the records as JSON lines, e.g. to stdout.
+type WriterSink struct {
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+func (s *WriterSink) Write(_ context.Context, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.Writer.Write(append(data, '\n'))
+	return err
+}
+
+// FileSink appends the records as JSON lines to a local file.
+type FileSink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *FileSink) Write(_ context.Context, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close() // nolint:errcheck
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	"github.com/giantswarm/exception-recommender/internal/audit"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// auditedFields are the fields of an AutomatedException compared in the audit records
+type auditedFields struct {
+	Labels      map[string]string                `json:"labels,omitempty"`
+	Annotations map[string]string                `json:"annotations,omitempty"`
+	Spec        policyAPI.AutomatedExceptionSpec `json:"spec"`
+}
+
+// policyExceptionFields are the fields of a PolicyException compared in the audit records
+type policyExceptionFields struct {
+	Labels      map[string]string             `json:"labels,omitempty"`
+	Annotations map[string]string             `json:"annotations,omitempty"`
+	Spec        policyAPI.PolicyExceptionSpec `json:"spec"`
+}
+
+// auditRecord describes the operation on the AutomatedException of the PolicyReport workload.
+// before is nil on creation, after is nil on deletion.
+func (r *PolicyReportReconciler) auditRecord(operation string, reason string, policyReport policyreport.PolicyReport, before *policyAPI.AutomatedException, after *policyAPI.AutomatedException) audit.Record {
+	record := automatedExceptionRecord("policyreport", operation, reason, before, after)
+	record.PolicyReport = &audit.Object{
+		Kind:            "PolicyReport",
+		Namespace:       policyReport.Namespace,
+		Name:            policyReport.Name,
+		UID:             string(policyReport.UID),
+		ResourceVersion: policyReport.ResourceVersion,
+	}
+	if policyReport.Scope != nil {
+		record.Workload = &audit.Object{
+			Kind:      policyReport.Scope.Kind,
+			Namespace: policyReport.Scope.Namespace,
+			Name:      policyReport.Scope.Name,
+			UID:       string(policyReport.Scope.UID),
+		}
+	}
+
+	for _, result := range policyReport.Results {
+		if result.Result != "fail" {
+			continue
+		}
+		record.FailingResults = append(record.FailingResults, audit.Result{
+			Policy:   result.Policy,
+			Rule:     result.Rule,
+			Category: result.Category,
+			Message:  result.Message,
+		})
+		if record.ManifestModes == nil {
+			record.ManifestModes = make(map[string]string)
+		}
+		record.ManifestModes[result.Policy] = GetPolicyManifestMode(result.Policy, r.PolicyManifestCache)
+	}
+
+	return record
+}
+
+// automatedExceptionRecord describes the operation on the AutomatedException along with its diff.
+func automatedExceptionRecord(controller string, operation string, reason string, before *policyAPI.AutomatedException, after *policyAPI.AutomatedException) audit.Record {
+	record := audit.Record{
+		Controller: controller,
+		Operation:  operation,
+		Reason:     reason,
+	}
+
+	var beforeFields, afterFields *auditedFields
+	if before != nil {
+		beforeFields = &auditedFields{Labels: before.Labels, Annotations: before.Annotations, Spec: before.Spec}
+		record.AutomatedException = audit.Object{Namespace: before.Namespace, Name: before.Name, UID: string(before.UID), ResourceVersion: before.ResourceVersion}
+		record.Policies = before.Spec.Policies
+	}
+	if after != nil {
+		afterFields = &auditedFields{Labels: after.Labels, Annotations: after.Annotations, Spec: after.Spec}
+		record.AutomatedException = audit.Object{Namespace: after.Namespace, Name: after.Name, UID: string(after.UID), ResourceVersion: after.ResourceVersion}
+		record.Policies = after.Spec.Policies
+	}
+	record.Diff = diff.Diff(beforeFields, afterFields)
+
+	return record
+}
+
+// policyExceptionRecord describes the operation on the PolicyException promoted from the AutomatedException along
+// with its diff. before is nil on creation.
+func policyExceptionRecord(operation string, automatedException policyAPI.AutomatedException, before *policyAPI.PolicyException, after *policyAPI.PolicyException) audit.Record {
+	record := audit.Record{
+		Controller:         "automatedexception",
+		Operation:          operation,
+		Reason:             audit.ReasonPromoted,
+		AutomatedException: audit.Object{Namespace: automatedException.Namespace, Name: automatedException.Name, UID: string(automatedException.UID), ResourceVersion: automatedException.ResourceVersion},
+		Policies:           after.Spec.Policies,
+		Workload: &audit.Object{
+			Kind:      automatedException.Labels[utils.KindLabelName],
+			Namespace: automatedException.Labels[utils.NamespaceLabelName],
+			Name:      automatedException.Labels[utils.NameLabelName],
+		},
+		PolicyException: &audit.Object{Kind: "PolicyException", Namespace: after.Namespace, Name: after.Name, UID: string(after.UID), ResourceVersion: after.ResourceVersion},
+	}
+
+	var beforeFields *policyExceptionFields
+	if before != nil {
+		beforeFields = &policyExceptionFields{Labels: before.Labels, Annotations: before.Annotations, Spec: before.Spec}
+	}
+	record.Diff = diff.Diff(beforeFields, &policyExceptionFields{Labels: after.Labels, Annotations: after.Annotations, Spec: after.Spec})
+
+	return record
+}
+
+// writeAudit writes the record to the audit trail when enabled. Updates which didn't change any audited field,
+// e.g. only bumping the resourceVersion, are not recorded. Failures are logged and counted,
+// they don't fail the reconciliation since the operation has already been performed.
+func writeAudit(ctx context.Context, trail *audit.Trail, record audit.Record) {
+	if trail == nil || (record.Operation == UpdateOp && record.Diff == "") {
+		return
+	}
+	if err := trail.Write(ctx, record); err != nil {
+		log.FromContext(ctx).Error(err, "unable to write audit record", "operation", record.Operation)
+	}
+}
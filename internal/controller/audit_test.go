@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+
+	"github.com/giantswarm/exception-recommender/internal/audit"
+)
+
+// recordingSink keeps the audit records in memory
+type recordingSink struct {
+	records []audit.Record
+}
+
+func (s *recordingSink) Write(_ context.Context, record audit.Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+var _ = Describe("Audit trail", func() {
+	const (
+		Namespace = "audited"
+		Category  = "Pod Security Standards (Restricted)"
+	)
+
+	It("records the creation, update and deletion of AutomatedExceptions", func() {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(policyAPI.AddToScheme(scheme)).To(Succeed())
+		Expect(policyreport.AddToScheme(scheme)).To(Succeed())
+
+		policyReport := &policyreport.PolicyReport{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: Namespace},
+			Scope:      &corev1.ObjectReference{Kind: "Deployment", Name: "app", Namespace: Namespace, UID: "audited-app"},
+			Results: []policyreport.PolicyReportResult{
+				{Policy: "require-run-as-nonroot", Rule: "run-as-nonroot", Category: Category, Result: "fail", Message: "runAsNonRoot must be true"},
+				{Policy: "disallow-capabilities", Category: Category, Result: "pass"},
+			},
+		}
+		sink := &recordingSink{}
+		reconciler := &PolicyReportReconciler{
+			Client:              fake.NewClientBuilder().WithScheme(scheme).WithObjects(policyReport).Build(),
+			TargetWorkloads:     []string{"Deployment"},
+			TargetCategories:    []string{Category},
+			Requeue:             utils.FixedRequeue{Interval: DefaultRequeueDuration, JitterPercent: 10},
+			PolicyManifestCache: expectedManifests("require-run-as-nonroot", "disallow-capabilities"),
+			Audit:               &audit.Trail{Sinks: map[string]audit.Sink{"memory": sink}},
+		}
+		reconcile := func(results ...policyreport.PolicyResult) {
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "app", Namespace: Namespace}, policyReport)).To(Succeed())
+			for i, result := range results {
+				policyReport.Results[i].Result = result
+			}
+			Expect(reconciler.Update(ctx, policyReport)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "app", Namespace: Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		reconcile("fail", "pass")
+		// Unchanged AutomatedExceptions are not recorded
+		reconcile("fail", "pass")
+		reconcile("fail", "fail")
+		reconcile("pass", "pass")
+
+		Expect(sink.records).To(HaveLen(3))
+
+		created := sink.records[0]
+		Expect(created.Controller).To(Equal("policyreport"))
+		Expect(created.Operation).To(Equal(CreateOp))
+		Expect(created.Reason).To(Equal(audit.ReasonFailing))
+		Expect(created.Time).NotTo(BeZero())
+		Expect(created.AutomatedException.Name).To(Equal("audited-app"))
+		Expect(created.AutomatedException.ResourceVersion).NotTo(BeEmpty())
+		Expect(created.Workload).To(Equal(&audit.Object{Kind: "Deployment", Namespace: Namespace, Name: "app", UID: "audited-app"}))
+		Expect(created.PolicyReport.Name).To(Equal("app"))
+		Expect(created.PolicyReport.ResourceVersion).NotTo(BeEmpty())
+		Expect(created.Policies).To(Equal([]string{"require-run-as-nonroot"}))
+		Expect(created.FailingResults).To(Equal([]audit.Result{
+			{Policy: "require-run-as-nonroot", Rule: "run-as-nonroot", Category: Category, Message: "runAsNonRoot must be true"},
+		}))
+		Expect(created.ManifestModes).To(Equal(map[string]string{"require-run-as-nonroot": ManifestExpectedMode}))
+		Expect(created.Diff).To(ContainSubstring("require-run-as-nonroot"))
+
+		updated := sink.records[1]
+		Expect(updated.Operation).To(Equal(UpdateOp))
+		Expect(updated.PolicyReport.ResourceVersion).NotTo(Equal(created.PolicyReport.ResourceVersion))
+		Expect(updated.Policies).To(ConsistOf("require-run-as-nonroot", "disallow-capabilities"))
+		Expect(updated.FailingResults).To(HaveLen(2))
+		Expect(updated.Diff).To(ContainSubstring("disallow-capabilities"))
+
+		deleted := sink.records[2]
+		Expect(deleted.Operation).To(Equal(DeleteOp))
+		Expect(deleted.Reason).To(Equal(audit.ReasonClean))
+		Expect(deleted.FailingResults).To(BeEmpty())
+		Expect(deleted.Policies).To(ConsistOf("require-run-as-nonroot", "disallow-capabilities"))
+	})
+})
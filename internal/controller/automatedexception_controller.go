@@ -0,0 +1,209 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	"github.com/giantswarm/exception-recommender/internal/audit"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// AutomatedExceptionReconciler promotes approved AutomatedExceptions into PolicyExceptions
+type AutomatedExceptionReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+	// MaxConcurrentReconciles is the number of AutomatedExceptions reconciled in parallel, 1 when unset
+	MaxConcurrentReconciles int
+	// Audit records the promotions into PolicyExceptions when set
+	Audit *audit.Trail
+}
+
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=automatedexceptions,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=policyexceptions,verbs=get;list;watch;create;update;patch
+
+func (r *AutomatedExceptionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	reconcilerResourceType := "AutomatedException"
+
+	var automatedException policyAPI.AutomatedException
+
+	if err := r.Get(ctx, req.NamespacedName, &automatedException); err != nil {
+		if !errors.IsNotFound(err) {
+			// Error fetching the AutomatedException
+			logger.Error(err, "unable to fetch AutomatedException")
+			// Metric for failed AutomatedException reconciliation
+			countFailure(reconcilerResourceType, FailureFetch, err)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Only approved drafts which haven't been promoted yet are handled
+	if !utils.IsApproved(automatedException) || !automatedException.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	// Stale drafts had their approval cleared, approving them again validates them again
+	if automatedException.Annotations[utils.ApprovalStatusAnnotation] == utils.ApprovalStatusPromoted {
+		return ctrl.Result{}, nil
+	}
+
+	// Validate the draft against the current PolicyReport failures
+	failedPolicies, err := getWorkloadFailedPolicies(ctx, r.Client, automatedException)
+	if err != nil {
+		logger.Error(err, "unable to list PolicyReports")
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
+	}
+
+	for _, policy := range automatedException.Spec.Policies {
+		if _, ok := failedPolicies[policy]; !ok {
+			// The approved draft no longer matches the workload, mark it as stale
+			logger.Info("Approved AutomatedException is stale, policy is no longer failing", "policy", policy)
+			return ctrl.Result{}, r.setApprovalStatus(ctx, &automatedException, utils.ApprovalStatusStale, time.Time{})
+		}
+	}
+
+	// The approval time is when the approval is first seen, the approved-at annotation of the reviewer isn't trusted
+	approvedAt := time.Now()
+
+	// Template PolicyException
+	policyException := utils.TemplatePolicyException(automatedException, approvedAt)
+
+	// Keep the existing PolicyException for the audit trail
+	var existing *policyAPI.PolicyException
+	if r.Audit != nil {
+		existing = &policyAPI.PolicyException{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(&policyException), existing); errors.IsNotFound(err) {
+			existing = nil
+		} else if err != nil {
+			logger.Error(err, "unable to fetch PolicyException", "policyException", client.ObjectKeyFromObject(&policyException))
+			countFailure(reconcilerResourceType, FailureFetch, err)
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Create or Update PolicyException
+	c := Controller{r.Client}
+	if op, err := c.CreateOrUpdate(ctx, &policyException); err != nil {
+		// Error creating or updating PolicyException
+		logger.Error(err, "unable to create or update PolicyException", "policyException", client.ObjectKeyFromObject(&policyException))
+		countFailure(reconcilerResourceType, FailureCreate, err)
+		return ctrl.Result{}, err
+	} else {
+		logger.Info("Promoted approved AutomatedException", "policyException", client.ObjectKeyFromObject(&policyException), "operation", op, "approvedBy", automatedException.Annotations[utils.ApprovedByAnnotation])
+		if op != NoOp {
+			writeAudit(ctx, r.Audit, policyExceptionRecord(op, automatedException, existing, &policyException))
+		}
+	}
+
+	return ctrl.Result{}, r.setApprovalStatus(ctx, &automatedException, utils.ApprovalStatusPromoted, approvedAt)
+}
+
+// getWorkloadFailedPolicies returns the failed policies, mapped to their category,
+// of the workload targeted by the AutomatedException.
+func getWorkloadFailedPolicies(ctx context.Context, c client.Client, automatedException policyAPI.AutomatedException) (map[string]string, error) {
+	failedPolicies := make(map[string]string)
+
+	kind := automatedException.Labels[utils.KindLabelName]
+	name := automatedException.Labels[utils.NameLabelName]
+	namespace := automatedException.Labels[utils.NamespaceLabelName]
+
+	var policyReports policyreport.PolicyReportList
+	if err := c.List(ctx, &policyReports, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for _, policyReport := range policyReports.Items {
+		if policyReport.Scope == nil || policyReport.Scope.Kind != kind || policyReport.Scope.Name != name {
+			continue
+		}
+		for _, result := range policyReport.Results {
+			if result.Result == "fail" {
+				failedPolicies[result.Policy] = result.Category
+			}
+		}
+	}
+
+	return failedPolicies, nil
+}
+
+// setApprovalStatus records the approval status on the AutomatedException. The approval of stale drafts is cleared,
+// so that they are managed by the PolicyReportReconciler again until they are approved again.
+func (r *AutomatedExceptionReconciler) setApprovalStatus(ctx context.Context, automatedException *policyAPI.AutomatedException, status string, approvedAt time.Time) error {
+	before := automatedException.DeepCopy()
+	patch := client.MergeFrom(before)
+
+	automatedException.Annotations[utils.ApprovalStatusAnnotation] = status
+	if status == utils.ApprovalStatusStale {
+		delete(automatedException.Annotations, utils.ApprovedByAnnotation)
+		delete(automatedException.Annotations, utils.ApprovedAtAnnotation)
+		delete(automatedException.Annotations, utils.ApprovalJustificationAnnotation)
+	}
+	if !approvedAt.IsZero() {
+		automatedException.Annotations[utils.ApprovedAtAnnotation] = approvedAt.UTC().Format(time.RFC3339)
+	}
+
+	if err := r.Patch(ctx, automatedException, patch); err != nil {
+		log.FromContext(ctx).Error(err, "unable to set approval status on AutomatedException", "status", status)
+		countFailure("AutomatedException", FailurePatch, err)
+		return client.IgnoreNotFound(err)
+	}
+
+	reason := audit.ReasonPromoted
+	if status == utils.ApprovalStatusStale {
+		reason = audit.ReasonStale
+	}
+	writeAudit(ctx, r.Audit, automatedExceptionRecord("automatedexception", UpdateOp, reason, before, automatedException))
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AutomatedExceptionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&policyAPI.AutomatedException{}).
+		WithOptions(crcontroller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		WithLogConstructor(logConstructor(r.Log, mgr, "automatedexception", "automatedexception")).
+		Complete(r)
+}
+
+// getAutomatedException returns the existing AutomatedException, or nil if it doesn't exist.
+func getAutomatedException(ctx context.Context, c client.Reader, name string, namespace string) (*policyAPI.AutomatedException, error) {
+	var existing policyAPI.AutomatedException
+
+	if err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &existing); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &existing, nil
+}
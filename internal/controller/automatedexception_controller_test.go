@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	wgpolicyk8s "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	"github.com/giantswarm/exception-recommender/internal/audit"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("AutomatedException controller", func() {
+
+	const (
+		PolicyReportName      = "0d4b2f5e-9d6a-4a55-8f3c-4b1f0a0c7d11"
+		PolicyReportNamespace = "default"
+		PolicyName            = "disallow-privilege-escalation"
+		ResourceName          = "approved-deployment"
+		ResourceNamespace     = "default"
+		ResourceKind          = "Deployment"
+		ResourceUID           = "7f0d5c4a-2b8e-4c1d-9a3f-6e5b4d3c2a10"
+		Approver              = "jane.doe"
+		Justification         = "Legacy workload"
+
+		timeout  = time.Second * 10
+		interval = time.Millisecond * 250
+	)
+
+	Describe("approving an AutomatedException", Ordered, func() {
+		BeforeAll(func() {
+			logger := zap.New(zap.WriteTo(GinkgoWriter))
+			ctx = log.IntoContext(context.Background(), logger)
+
+			// Create a PolicyReport with a failed result for the workload
+			policyReport := &wgpolicyk8s.PolicyReport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      PolicyReportName,
+					Namespace: PolicyReportNamespace,
+				},
+				Scope: &corev1.ObjectReference{
+					APIVersion: "apps/v1",
+					Kind:       ResourceKind,
+					Name:       ResourceName,
+					Namespace:  ResourceNamespace,
+					UID:        ResourceUID,
+				},
+				Results: []wgpolicyk8s.PolicyReportResult{
+					{
+						Category: "Pod Security Standards (Baseline)",
+						Policy:   PolicyName,
+						Result:   "fail",
+						Source:   "kyverno",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policyReport)).Should(Succeed())
+
+			// Create an approved AutomatedException for the workload
+			automatedException := utils.TemplateAutomatedException(*policyReport, []string{PolicyName}, destinationNamespace)
+			automatedException.Annotations = map[string]string{
+				utils.ApprovedByAnnotation:            Approver,
+				utils.ApprovalJustificationAnnotation: Justification,
+			}
+			Expect(k8sClient.Create(ctx, &automatedException)).Should(Succeed())
+		})
+
+		lookupKey := types.NamespacedName{Name: ResourceUID, Namespace: destinationNamespace}
+
+		When("the approved policies are still failing", func() {
+			It("must create a Giant Swarm PolicyException", func() {
+				policyException := policyAPI.PolicyException{}
+				Eventually(func() error {
+					return k8sClient.Get(ctx, lookupKey, &policyException)
+				}, timeout, interval).Should(Succeed())
+
+				Expect(policyException.Spec.Policies).To(ConsistOf(PolicyName))
+				Expect(policyException.Annotations).To(HaveKeyWithValue(utils.ApprovedByAnnotation, Approver))
+				Expect(policyException.Annotations).To(HaveKeyWithValue(utils.ApprovalJustificationAnnotation, Justification))
+				Expect(policyException.Annotations).To(HaveKey(utils.ApprovedAtAnnotation))
+			})
+
+			It("must mark the AutomatedException as promoted", func() {
+				automatedException := policyAPI.AutomatedException{}
+				Eventually(func() string {
+					if err := k8sClient.Get(ctx, lookupKey, &automatedException); err != nil {
+						return ""
+					}
+					return automatedException.Annotations[utils.ApprovalStatusAnnotation]
+				}, timeout, interval).Should(Equal(utils.ApprovalStatusPromoted))
+			})
+		})
+	})
+
+	Describe("stale approvals", func() {
+		It("clears the approval of stale drafts and promotes them once approved again", func() {
+			ctx := context.Background()
+			policyReport := predicatePolicyReport("team-a", "api", "pass")
+			automatedException := utils.TemplateAutomatedException(*policyReport, []string{"require-run-as-nonroot"}, "team-a")
+			automatedException.Annotations = map[string]string{
+				utils.ApprovedByAnnotation:            Approver,
+				utils.ApprovalJustificationAnnotation: Justification,
+			}
+			sink := &recordingSink{}
+			reconciler := &AutomatedExceptionReconciler{
+				Client: watchClient(policyReport, &automatedException),
+				Audit:  &audit.Trail{Sinks: map[string]audit.Sink{"memory": sink}},
+			}
+			key := client.ObjectKeyFromObject(&automatedException)
+
+			// The approved policy passes
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, &automatedException)).To(Succeed())
+			Expect(automatedException.Annotations).To(HaveKeyWithValue(utils.ApprovalStatusAnnotation, utils.ApprovalStatusStale))
+			Expect(utils.IsApproved(automatedException)).To(BeFalse())
+			Expect(automatedException.Annotations).NotTo(HaveKey(utils.ApprovalJustificationAnnotation))
+
+			// Approved again once failing again
+			policyReport.Results[0].Result = "fail"
+			Expect(reconciler.Update(ctx, policyReport)).To(Succeed())
+			automatedException.Annotations[utils.ApprovedByAnnotation] = Approver
+			Expect(reconciler.Update(ctx, &automatedException)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.Get(ctx, key, &policyAPI.PolicyException{})).To(Succeed())
+			Expect(reconciler.Get(ctx, key, &automatedException)).To(Succeed())
+			Expect(automatedException.Annotations).To(HaveKeyWithValue(utils.ApprovalStatusAnnotation, utils.ApprovalStatusPromoted))
+
+			Expect(sink.records).To(ConsistOf(
+				And(
+					HaveField("Controller", "automatedexception"),
+					HaveField("Operation", UpdateOp),
+					HaveField("Reason", audit.ReasonStale),
+					HaveField("AutomatedException.Name", key.Name),
+					HaveField("Diff", ContainSubstring(Justification)),
+				),
+				And(
+					HaveField("Controller", "automatedexception"),
+					HaveField("Operation", CreateOp),
+					HaveField("Reason", audit.ReasonPromoted),
+					HaveField("PolicyException.Name", key.Name),
+					HaveField("Workload.Name", "api"),
+					HaveField("Diff", ContainSubstring("require-run-as-nonroot")),
+				),
+				And(
+					HaveField("Controller", "automatedexception"),
+					HaveField("Operation", UpdateOp),
+					HaveField("Reason", audit.ReasonPromoted),
+					HaveField("PolicyException", BeNil()),
+					HaveField("Diff", ContainSubstring(utils.ApprovalStatusPromoted)),
+				),
+			))
+		})
+	})
+
+	It("stamps the approval time itself and doesn't mark the PolicyException as managed", func() {
+		ctx := context.Background()
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		automatedException := utils.TemplateAutomatedException(*policyReport, []string{"require-run-as-nonroot"}, "team-a")
+		automatedException.Annotations = map[string]string{
+			utils.ApprovedByAnnotation: Approver,
+			utils.ApprovedAtAnnotation: "2020-01-01T00:00:00Z",
+		}
+		reconciler := &AutomatedExceptionReconciler{Client: watchClient(policyReport, &automatedException)}
+		key := client.ObjectKeyFromObject(&automatedException)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		var policyException policyAPI.PolicyException
+		Expect(reconciler.Get(ctx, key, &policyException)).To(Succeed())
+		approvedAt, err := time.Parse(time.RFC3339, policyException.Annotations[utils.ApprovedAtAnnotation])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(approvedAt).To(BeTemporally("~", time.Now(), time.Minute))
+		Expect(policyException.Labels).NotTo(HaveKey(utils.AppLabelName))
+		Expect(policyException.Labels).To(HaveKeyWithValue(utils.NameLabelName, "api"))
+	})
+})
@@ -0,0 +1,111 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+)
+
+// CircuitBreakerReconciler closes the ExceptionCircuitBreaker when the resume annotation is set
+// and keeps the CircuitBreakerOpenMetric in sync with its status.
+type CircuitBreakerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Log      logr.Logger
+	Recorder events.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=exceptioncircuitbreakers,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=exceptioncircuitbreakers/status,verbs=get;update;patch
+
+func (r *CircuitBreakerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	reconcilerResourceType := "ExceptionCircuitBreaker"
+
+	var breaker recommenderAPI.ExceptionCircuitBreaker
+	if err := r.Get(ctx, req.NamespacedName, &breaker); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "unable to fetch ExceptionCircuitBreaker")
+			countFailure(reconcilerResourceType, FailureFetch, err)
+		} else {
+			CircuitBreakerOpenMetric.Set(0)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if breaker.Annotations[recommenderAPI.ResumeAnnotation] != "true" {
+		if breaker.Status.Open {
+			CircuitBreakerOpenMetric.Set(1)
+		} else {
+			CircuitBreakerOpenMetric.Set(0)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Close the circuit breaker before dropping the annotation, so that a failure is retried
+	if breaker.Status.Open {
+		patch := client.MergeFrom(breaker.DeepCopy())
+		breaker.Status.Open = false
+		meta.SetStatusCondition(&breaker.Status.Conditions, metav1.Condition{
+			Type:    recommenderAPI.CreationPausedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  ReasonResumed,
+			Message: fmt.Sprintf("Creation was resumed with the %s annotation", recommenderAPI.ResumeAnnotation),
+		})
+		if err := r.Status().Patch(ctx, &breaker, patch); err != nil {
+			logger.Error(err, "unable to update ExceptionCircuitBreaker status")
+			countFailure(reconcilerResourceType, FailurePatch, err)
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		CircuitBreakerOpenMetric.Set(0)
+		logger.Info("Closed the circuit breaker, creation of AutomatedExceptions is resumed")
+		if r.Recorder != nil {
+			r.Recorder.Eventf(&breaker, nil, corev1.EventTypeNormal, "CircuitBreakerClosed", "Resume", "Creation of AutomatedExceptions was resumed")
+		}
+	}
+
+	patch := client.MergeFrom(breaker.DeepCopy())
+	delete(breaker.Annotations, recommenderAPI.ResumeAnnotation)
+	if err := r.Patch(ctx, &breaker, patch); err != nil {
+		logger.Error(err, "unable to remove resume annotation")
+		countFailure(reconcilerResourceType, FailurePatch, err)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CircuitBreakerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&recommenderAPI.ExceptionCircuitBreaker{}).
+		WithLogConstructor(logConstructor(r.Log, mgr, "exceptioncircuitbreaker", "exceptioncircuitbreaker")).
+		Complete(r)
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"encoding/json"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+// Drifts of the DriftCorrectionsMetric
+const (
+	// DriftDeleted is an AutomatedException deleted by someone else
+	DriftDeleted = "deleted"
+	// DriftModified is an AutomatedException whose spec or labels were changed by someone else
+	DriftModified = "modified"
+)
+
+// appliedExceptions remembers the managed fields of the AutomatedExceptions last written by the PolicyReportReconciler,
+// so that changes made by others are told apart from changes of the PolicyReport results.
+type appliedExceptions struct {
+	mu     sync.Mutex
+	fields map[types.NamespacedName]string
+}
+
+var appliedAutomatedExceptions = &appliedExceptions{
+	fields: make(map[types.NamespacedName]string),
+}
+
+// managedFields serializes the spec and labels of the AutomatedException, which CreateOrUpdate restores.
+func managedFields(automatedException *policyAPI.AutomatedException) string {
+	fields, _ := json.Marshal(struct {
+		Labels map[string]string                `json:"labels"`
+		Spec   policyAPI.AutomatedExceptionSpec `json:"spec"`
+	}{automatedException.Labels, automatedException.Spec})
+	return string(fields)
+}
+
+// Set records the AutomatedException as written by the PolicyReportReconciler.
+func (a *appliedExceptions) Set(key types.NamespacedName, automatedException *policyAPI.AutomatedException) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.fields[key] = managedFields(automatedException)
+}
+
+// Forget stops tracking the AutomatedException, once deleted or no longer written by the PolicyReportReconciler.
+func (a *appliedExceptions) Forget(key types.NamespacedName) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.fields, key)
+}
+
+// Drift returns how the existing AutomatedException was changed by others since it was last written, if it was.
+// AutomatedExceptions written before the recommender started are never considered drifted.
+func (a *appliedExceptions) Drift(key types.NamespacedName, existing *policyAPI.AutomatedException) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	fields, ok := a.fields[key]
+	switch {
+	case !ok:
+		return ""
+	case existing == nil:
+		return DriftDeleted
+	case managedFields(existing) != fields:
+		return DriftModified
+	}
+	return ""
+}
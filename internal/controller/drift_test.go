@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+var _ = Describe("Drift", func() {
+	ctx := context.Background()
+	const namespace = "drift"
+	key := types.NamespacedName{Namespace: namespace, Name: namespace + "-api"}
+
+	var (
+		reconciler   *PolicyReportReconciler
+		policyReport *policyreport.PolicyReport
+	)
+
+	reconcileReport := func() *policyAPI.AutomatedException {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)})
+		Expect(err).NotTo(HaveOccurred())
+
+		var automatedException policyAPI.AutomatedException
+		if err := reconciler.Get(ctx, key, &automatedException); apierrors.IsNotFound(err) {
+			return nil
+		} else {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		return &automatedException
+	}
+	corrections := func(drift string) float64 {
+		return testutil.ToFloat64(DriftCorrectionsMetric.WithLabelValues(namespace, drift))
+	}
+
+	BeforeEach(func() {
+		policyReport = predicatePolicyReport(namespace, "api", "fail")
+		reconciler = predicateReconciler()
+		reconciler.Client = watchClient(policyReport)
+		Expect(reconcileReport()).NotTo(BeNil())
+	})
+
+	AfterEach(func() {
+		appliedAutomatedExceptions.Forget(key)
+	})
+
+	It("restores deleted AutomatedExceptions", func() {
+		before := corrections(DriftDeleted)
+		Expect(reconciler.Delete(ctx, &policyAPI.AutomatedException{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}})).To(Succeed())
+
+		Expect(reconcileReport()).NotTo(BeNil())
+		Expect(corrections(DriftDeleted)).To(Equal(before + 1))
+	})
+
+	It("restores modified AutomatedExceptions", func() {
+		before := corrections(DriftModified)
+		automatedException := reconcileReport()
+		automatedException.Spec.Policies = append(automatedException.Spec.Policies, "disallow-privileged-containers")
+		automatedException.Labels["team"] = "platform"
+		Expect(reconciler.Update(ctx, automatedException)).To(Succeed())
+
+		restored := reconcileReport()
+		Expect(restored.Spec.Policies).To(ConsistOf("require-run-as-nonroot"))
+		Expect(restored.Labels).NotTo(HaveKey("team"))
+		Expect(corrections(DriftModified)).To(Equal(before + 1))
+	})
+
+	It("doesn't count changes of the results as drift", func() {
+		deleted, modified := corrections(DriftDeleted), corrections(DriftModified)
+
+		// A second failing policy updates the AutomatedException
+		reconciler.PolicyManifestCache = expectedManifests("require-run-as-nonroot", "require-labels")
+		policyReport.Results[1].Result = "fail"
+		policyReport.Results[1].Category = predicateCategory
+		Expect(reconciler.Update(ctx, policyReport)).To(Succeed())
+		Expect(reconcileReport().Spec.Policies).To(ConsistOf("require-run-as-nonroot", "require-labels"))
+
+		// Clean results delete it, failing results create it again
+		for i := range policyReport.Results {
+			policyReport.Results[i].Result = "pass"
+		}
+		Expect(reconciler.Update(ctx, policyReport)).To(Succeed())
+		Expect(reconcileReport()).To(BeNil())
+		policyReport.Results[0].Result = "fail"
+		Expect(reconciler.Update(ctx, policyReport)).To(Succeed())
+		Expect(reconcileReport()).NotTo(BeNil())
+
+		Expect(corrections(DriftDeleted)).To(Equal(deleted))
+		Expect(corrections(DriftModified)).To(Equal(modified))
+	})
+})
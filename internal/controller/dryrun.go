@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+// DryRunOperation is an operation the PolicyReportReconciler would have performed.
+type DryRunOperation struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Operation string   `json:"operation"`
+	Policies  []string `json:"policies,omitempty"`
+	Diff      string   `json:"diff,omitempty"`
+}
+
+// DryRunRecorder keeps track of the operations the PolicyReportReconciler would have performed
+// on AutomatedExceptions in dry-run mode, and serves them over HTTP.
+type DryRunRecorder struct {
+	mu         sync.Mutex
+	operations map[types.NamespacedName]DryRunOperation
+}
+
+func NewDryRunRecorder() *DryRunRecorder {
+	return &DryRunRecorder{
+		operations: make(map[types.NamespacedName]DryRunOperation),
+	}
+}
+
+// Record computes and logs the operation needed to go from the existing AutomatedException to the desired one.
+// A nil existing AutomatedException is created, a nil desired AutomatedException is deleted.
+func (d *DryRunRecorder) Record(ctx context.Context, key types.NamespacedName, existing *policyAPI.AutomatedException, desired *policyAPI.AutomatedException) {
+	operation := DryRunOperation{
+		Namespace: key.Namespace,
+		Name:      key.Name,
+	}
+
+	switch {
+	case existing == nil && desired == nil:
+		// Nothing to do
+		d.forget(key)
+		return
+	case existing == nil:
+		operation.Operation = CreateOp
+		operation.Policies = desired.Spec.Policies
+		operation.Diff = diff.Diff(nil, desired.Spec)
+	case desired == nil:
+		operation.Operation = DeleteOp
+		operation.Policies = existing.Spec.Policies
+	case equality.Semantic.DeepEqual(existing.Spec, desired.Spec) && equality.Semantic.DeepEqual(existing.Labels, desired.Labels):
+		operation.Operation = NoOp
+		operation.Policies = desired.Spec.Policies
+	default:
+		operation.Operation = UpdateOp
+		operation.Policies = desired.Spec.Policies
+		operation.Diff = diff.Diff(existing.Spec, desired.Spec)
+	}
+
+	if operation.Operation != NoOp {
+		log.FromContext(ctx).Info("[dry-run] AutomatedException would be changed", "automatedException", key, "operation", operation.Operation, "diff", operation.Diff)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.operations[key] = operation
+	d.updateMetrics()
+}
+
+func (d *DryRunRecorder) forget(key types.NamespacedName) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.operations, key)
+	d.updateMetrics()
+}
+
+// updateMetrics sets the planned operations gauge, the lock must be held.
+func (d *DryRunRecorder) updateMetrics() {
+	counts := d.counts()
+	for _, operation := range []string{CreateOp, UpdateOp, DeleteOp, NoOp} {
+		DryRunOperationsMetric.WithLabelValues(operation).Set(float64(counts[operation]))
+	}
+}
+
+// counts returns the number of planned operations by type, the lock must be held.
+func (d *DryRunRecorder) counts() map[string]int {
+	counts := make(map[string]int)
+	for _, operation := range d.operations {
+		counts[operation.Operation]++
+	}
+	return counts
+}
+
+// ServeHTTP returns the planned operations as JSON.
+func (d *DryRunRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	d.mu.Lock()
+	response := struct {
+		Counts     map[string]int    `json:"counts"`
+		Operations []DryRunOperation `json:"operations"`
+	}{
+		Counts:     d.counts(),
+		Operations: make([]DryRunOperation, 0, len(d.operations)),
+	}
+	for _, operation := range d.operations {
+		response.Operations = append(response.Operations, operation)
+	}
+	d.mu.Unlock()
+
+	sort.Slice(response.Operations, func(i, j int) bool {
+		if response.Operations[i].Namespace != response.Operations[j].Namespace {
+			return response.Operations[i].Namespace < response.Operations[j].Namespace
+		}
+		return response.Operations[i].Name < response.Operations[j].Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.FromContext(req.Context()).Error(err, "unable to encode dry-run operations")
+	}
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+var _ = Describe("DryRunRecorder", func() {
+	var recorder *DryRunRecorder
+
+	key := types.NamespacedName{Name: "e6d75155-e7bd-4df0-84d5-e1b2416cb2b9", Namespace: "policy-exceptions"}
+	existing := &policyAPI.AutomatedException{
+		Spec: policyAPI.AutomatedExceptionSpec{Policies: []string{"require-run-as-nonroot"}},
+	}
+
+	BeforeEach(func() {
+		recorder = NewDryRunRecorder()
+	})
+
+	DescribeTable("recording an operation",
+		func(existing *policyAPI.AutomatedException, desired *policyAPI.AutomatedException, expectedOperation string) {
+			recorder.Record(context.Background(), key, existing, desired)
+
+			Expect(recorder.operations).To(HaveKeyWithValue(key, HaveField("Operation", expectedOperation)))
+		},
+		Entry("creates missing AutomatedExceptions", nil, existing, CreateOp),
+		Entry("deletes unneeded AutomatedExceptions", existing, nil, DeleteOp),
+		Entry("keeps matching AutomatedExceptions", existing, existing.DeepCopy(), NoOp),
+		Entry("updates changed AutomatedExceptions", existing, &policyAPI.AutomatedException{
+			Spec: policyAPI.AutomatedExceptionSpec{Policies: []string{"require-run-as-nonroot", "disallow-privilege-escalation"}},
+		}, UpdateOp),
+	)
+
+	It("serves the planned operations", func() {
+		recorder.Record(context.Background(), key, nil, existing)
+
+		response := httptest.NewRecorder()
+		recorder.ServeHTTP(response, httptest.NewRequest("GET", "/dry-run", nil))
+
+		var body struct {
+			Counts     map[string]int    `json:"counts"`
+			Operations []DryRunOperation `json:"operations"`
+		}
+		Expect(json.Unmarshal(response.Body.Bytes(), &body)).To(Succeed())
+		Expect(body.Counts).To(HaveKeyWithValue(CreateOp, 1))
+		Expect(body.Operations).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,221 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	"github.com/giantswarm/exception-recommender/internal/audit"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// ExpiryReconciler warns about, expires and renews AutomatedExceptions with an expiry date
+type ExpiryReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	Log           logr.Logger
+	Recorder      events.EventRecorder
+	ExceptionTTL  utils.ExceptionTTL
+	WarningWindow time.Duration
+	ExpiredAction string
+	// Audit records the expiry and the renewal of AutomatedExceptions when set
+	Audit *audit.Trail
+	// MaxConcurrentReconciles is the number of AutomatedExceptions reconciled in parallel, 1 when unset
+	MaxConcurrentReconciles int
+
+	// warned keeps track of the expiry dates which have already been warned about
+	warned sync.Map
+}
+
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=automatedexceptions,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+func (r *ExpiryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	reconcilerResourceType := "AutomatedException"
+
+	var automatedException policyAPI.AutomatedException
+
+	if err := r.Get(ctx, req.NamespacedName, &automatedException); err != nil {
+		if !errors.IsNotFound(err) {
+			// Error fetching the AutomatedException
+			logger.Error(err, "unable to fetch AutomatedException")
+			// Metric for failed AutomatedException reconciliation
+			countFailure(reconcilerResourceType, FailureFetch, err)
+		}
+		r.warned.Delete(req.NamespacedName)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Ignore AutomatedExceptions being deleted or without an expiry date
+	expiresAt, ok := utils.ExpiresAt(automatedException.Annotations)
+	if !ok || !automatedException.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Renew the AutomatedException on request
+	if _, ok := automatedException.Annotations[utils.RenewRequestAnnotation]; ok {
+		return r.renew(ctx, &automatedException)
+	}
+
+	// Already expired
+	if automatedException.Annotations[utils.ExpiredAnnotation] == "true" {
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+
+	if !now.Before(expiresAt) {
+		return r.expire(ctx, &automatedException)
+	}
+
+	warnAt := expiresAt.Add(-r.WarningWindow)
+	if !now.Before(warnAt) {
+		// Warn only once per expiry date
+		if previous, loaded := r.warned.Swap(req.NamespacedName, expiresAt); !loaded || !previous.(time.Time).Equal(expiresAt) {
+			if r.Recorder != nil {
+				r.Recorder.Eventf(&automatedException, nil, corev1.EventTypeWarning, "ExpiringSoon", "Expire",
+					"AutomatedException expires at %s", expiresAt.Format(time.RFC3339))
+			}
+			ExpiryWarningsMetric.WithLabelValues(automatedException.Namespace).Inc()
+		}
+		// Requeue at expiry time
+		return ctrl.Result{RequeueAfter: expiresAt.Sub(now)}, nil
+	}
+
+	// Requeue when the warning window starts
+	return ctrl.Result{RequeueAfter: warnAt.Sub(now)}, nil
+}
+
+// expire marks the expired AutomatedException, and revokes its Policies with the delete action. The AutomatedException
+// is kept so that it isn't drafted again, it is deleted by the PolicyReportReconciler once the workload no longer
+// fails or is deleted.
+func (r *ExpiryReconciler) expire(ctx context.Context, automatedException *policyAPI.AutomatedException) (ctrl.Result, error) {
+	before := automatedException.DeepCopy()
+	patch := client.MergeFrom(before)
+	automatedException.Annotations[utils.ExpiredAnnotation] = "true"
+	if r.ExpiredAction == utils.ExpiredActionDelete {
+		automatedException.Annotations[utils.ExpiredPoliciesAnnotation] = strings.Join(automatedException.Spec.Policies, ",")
+		automatedException.Spec.Policies = []string{}
+	}
+	if err := r.Patch(ctx, automatedException, patch); err != nil {
+		log.FromContext(ctx).Error(err, "unable to mark AutomatedException as expired")
+		countFailure("AutomatedException", FailurePatch, err)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log.FromContext(ctx).Info("Marked AutomatedException as expired", "action", r.ExpiredAction)
+	writeAudit(ctx, r.Audit, automatedExceptionRecord("expiry", UpdateOp, audit.ReasonExpired, before, automatedException))
+
+	if r.Recorder != nil {
+		r.Recorder.Eventf(automatedException, nil, corev1.EventTypeWarning, "Expired", "Expire",
+			"AutomatedException expired, action: %s", r.ExpiredAction)
+	}
+	ExpiredExceptionsMetric.WithLabelValues(automatedException.Namespace, r.ExpiredAction).Inc()
+	r.warned.Delete(client.ObjectKeyFromObject(automatedException))
+
+	return ctrl.Result{}, nil
+}
+
+// renew extends the expiry date of the AutomatedException only if the workload still fails all its policies.
+func (r *ExpiryReconciler) renew(ctx context.Context, automatedException *policyAPI.AutomatedException) (ctrl.Result, error) {
+	failedPolicies, err := getWorkloadFailedPolicies(ctx, r.Client, *automatedException)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "unable to list PolicyReports")
+		countFailure("AutomatedException", FailureFetch, err)
+		return ctrl.Result{}, err
+	}
+
+	before := automatedException.DeepCopy()
+	patch := client.MergeFrom(before)
+	delete(automatedException.Annotations, utils.RenewRequestAnnotation)
+	reason := audit.ReasonRenewed
+
+	// The Policies revoked on expiry are renewed too
+	policies := automatedException.Spec.Policies
+	if expiredPolicies, ok := automatedException.Annotations[utils.ExpiredPoliciesAnnotation]; ok {
+		policies = strings.Split(expiredPolicies, ",")
+	}
+
+	// Check the policies are still failing
+	policyCategories := make(map[string]string)
+	var passingPolicies []string
+	for _, policy := range policies {
+		if category, ok := failedPolicies[policy]; ok {
+			policyCategories[policy] = category
+		} else {
+			passingPolicies = append(passingPolicies, policy)
+		}
+	}
+
+	if len(passingPolicies) == 0 {
+		if ttl := r.ExceptionTTL.For(policyCategories); ttl > 0 {
+			automatedException.Annotations[utils.ExpiresAtAnnotation] = time.Now().Add(ttl).UTC().Format(time.RFC3339)
+		} else {
+			delete(automatedException.Annotations, utils.ExpiresAtAnnotation)
+		}
+		delete(automatedException.Annotations, utils.ExpiredAnnotation)
+		delete(automatedException.Annotations, utils.ExpiredPoliciesAnnotation)
+		automatedException.Spec.Policies = policies
+		if r.Recorder != nil {
+			r.Recorder.Eventf(automatedException, nil, corev1.EventTypeNormal, "Renewed", "Renew",
+				"AutomatedException renewed until %s", automatedException.Annotations[utils.ExpiresAtAnnotation])
+		}
+		RenewalsMetric.WithLabelValues(automatedException.Namespace, "renewed").Inc()
+	} else {
+		if r.Recorder != nil {
+			r.Recorder.Eventf(automatedException, nil, corev1.EventTypeWarning, "RenewalRejected", "Renew",
+				"AutomatedException not renewed, workload no longer fails policies %v", passingPolicies)
+		}
+		RenewalsMetric.WithLabelValues(automatedException.Namespace, "rejected").Inc()
+		reason = audit.ReasonRenewalRejected
+	}
+
+	if err := r.Patch(ctx, automatedException, patch); err != nil {
+		log.FromContext(ctx).Error(err, "unable to renew AutomatedException")
+		countFailure("AutomatedException", FailurePatch, err)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	writeAudit(ctx, r.Audit, automatedExceptionRecord("expiry", UpdateOp, reason, before, automatedException))
+	r.warned.Delete(client.ObjectKeyFromObject(automatedException))
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ExpiryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("automatedexception-expiry").
+		For(&policyAPI.AutomatedException{}).
+		WithOptions(crcontroller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		WithLogConstructor(logConstructor(r.Log, mgr, "automatedexception-expiry", "automatedexception")).
+		Complete(r)
+}
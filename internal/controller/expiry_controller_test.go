@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	"github.com/giantswarm/exception-recommender/internal/audit"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("Expiry", func() {
+	ctx := context.Background()
+	ttl := utils.ExceptionTTL{Default: 24 * time.Hour}
+
+	It("revokes the policies of expired AutomatedExceptions without drafting them again", func() {
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		automatedException := utils.TemplateAutomatedException(*policyReport, []string{"require-run-as-nonroot"}, "team-a")
+		expiresAt := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		automatedException.Annotations = map[string]string{utils.ExpiresAtAnnotation: expiresAt}
+		c := watchClient(policyReport, &automatedException)
+		key := client.ObjectKeyFromObject(&automatedException)
+		defer appliedAutomatedExceptions.Forget(key)
+
+		expiry := &ExpiryReconciler{Client: c, ExpiredAction: utils.ExpiredActionDelete, ExceptionTTL: ttl}
+		_, err := expiry.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		var expired policyAPI.AutomatedException
+		Expect(c.Get(ctx, key, &expired)).To(Succeed())
+		Expect(expired.Spec.Policies).To(BeEmpty())
+		Expect(expired.Annotations).To(HaveKeyWithValue(utils.ExpiredAnnotation, "true"))
+		Expect(expired.Annotations).To(HaveKeyWithValue(utils.ExpiredPoliciesAnnotation, "require-run-as-nonroot"))
+
+		// The workload still fails, but the AutomatedException isn't drafted again
+		reconciler := predicateReconciler()
+		reconciler.Client = c
+		reconciler.ExceptionTTL = ttl
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)})
+		Expect(err).NotTo(HaveOccurred())
+		var unchanged policyAPI.AutomatedException
+		Expect(c.Get(ctx, key, &unchanged)).To(Succeed())
+		Expect(unchanged.Spec.Policies).To(BeEmpty())
+
+		// Renewing restores the revoked policies
+		unchanged.Annotations[utils.RenewRequestAnnotation] = ""
+		Expect(c.Update(ctx, &unchanged)).To(Succeed())
+		_, err = expiry.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		var renewed policyAPI.AutomatedException
+		Expect(c.Get(ctx, key, &renewed)).To(Succeed())
+		Expect(renewed.Spec.Policies).To(ConsistOf("require-run-as-nonroot"))
+		Expect(renewed.Annotations).NotTo(HaveKey(utils.ExpiredAnnotation))
+		Expect(renewed.Annotations).NotTo(HaveKey(utils.ExpiredPoliciesAnnotation))
+		Expect(renewed.Annotations[utils.ExpiresAtAnnotation]).NotTo(Equal(expiresAt))
+	})
+
+	It("audits the expiry marks and the renewals", func() {
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		automatedException := utils.TemplateAutomatedException(*policyReport, []string{"require-run-as-nonroot"}, "team-a")
+		automatedException.Annotations = map[string]string{utils.ExpiresAtAnnotation: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)}
+		c := watchClient(policyReport, &automatedException)
+		key := client.ObjectKeyFromObject(&automatedException)
+		sink := &recordingSink{}
+		expiry := &ExpiryReconciler{
+			Client:        c,
+			Recorder:      events.NewFakeRecorder(10),
+			ExpiredAction: utils.ExpiredActionMark,
+			ExceptionTTL:  ttl,
+			Audit:         &audit.Trail{Sinks: map[string]audit.Sink{"memory": sink}},
+		}
+
+		_, err := expiry.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, key, &automatedException)).To(Succeed())
+		Expect(automatedException.Annotations).To(HaveKeyWithValue(utils.ExpiredAnnotation, "true"))
+
+		automatedException.Annotations[utils.RenewRequestAnnotation] = ""
+		Expect(c.Update(ctx, &automatedException)).To(Succeed())
+		_, err = expiry.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sink.records).To(HaveExactElements(
+			And(
+				HaveField("Controller", "expiry"),
+				HaveField("Operation", UpdateOp),
+				HaveField("Reason", audit.ReasonExpired),
+				HaveField("Diff", ContainSubstring(utils.ExpiredAnnotation)),
+			),
+			And(
+				HaveField("Controller", "expiry"),
+				HaveField("Operation", UpdateOp),
+				HaveField("Reason", audit.ReasonRenewed),
+				HaveField("Diff", ContainSubstring(utils.RenewRequestAnnotation)),
+			),
+		))
+	})
+
+	It("keeps the review and renew annotations when updating AutomatedExceptions", func() {
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		automatedException := utils.TemplateAutomatedException(*policyReport, []string{"require-labels"}, "team-a")
+		expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		automatedException.Annotations = map[string]string{
+			utils.ExpiresAtAnnotation:             expiresAt,
+			utils.ApprovalJustificationAnnotation: "Legacy workload",
+			utils.RenewRequestAnnotation:          "",
+			utils.CleanSinceAnnotation:            time.Now().UTC().Format(time.RFC3339),
+		}
+		c := watchClient(policyReport, &automatedException)
+		key := client.ObjectKeyFromObject(&automatedException)
+		defer appliedAutomatedExceptions.Forget(key)
+
+		reconciler := predicateReconciler()
+		reconciler.Client = c
+		reconciler.ExceptionTTL = ttl
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)})
+		Expect(err).NotTo(HaveOccurred())
+
+		var updated policyAPI.AutomatedException
+		Expect(c.Get(ctx, key, &updated)).To(Succeed())
+		Expect(updated.Spec.Policies).To(ConsistOf("require-run-as-nonroot"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(utils.ExpiresAtAnnotation, expiresAt))
+		Expect(updated.Annotations).To(HaveKeyWithValue(utils.ApprovalJustificationAnnotation, "Legacy workload"))
+		Expect(updated.Annotations).To(HaveKey(utils.RenewRequestAnnotation))
+		Expect(updated.Annotations).NotTo(HaveKey(utils.CleanSinceAnnotation))
+	})
+})
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// Decision steps, in the order they are taken by the PolicyReportReconciler
+const (
+	StepScope     = "scope"
+	StepNamespace = "namespace"
+	StepKind      = "kind"
+	StepCategory  = "category"
+	StepResult    = "result"
+	StepProtected = "protected"
+	StepManifest  = "manifest"
+	StepException = "exception"
+)
+
+const (
+	OutcomeAccepted = "accepted"
+	OutcomeSkipped  = "skipped"
+)
+
+// Decision is a single step of the decision path for a PolicyReport.
+type Decision struct {
+	Step    string `json:"step"`
+	Policy  string `json:"policy,omitempty"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason"`
+}
+
+func (r *Recommendation) decide(step string, policy string, outcome string, reason string) {
+	r.Decisions = append(r.Decisions, Decision{
+		Step:    step,
+		Policy:  policy,
+		Outcome: outcome,
+		Reason:  reason,
+	})
+}
+
+// Explanation is the trace of every decision taken for a workload and the resulting AutomatedException.
+type Explanation struct {
+	Namespace          string                        `json:"namespace"`
+	Kind               string                        `json:"kind"`
+	Name               string                        `json:"name"`
+	PolicyReport       string                        `json:"policyReport,omitempty"`
+	Decisions          []Decision                    `json:"decisions"`
+	AutomatedException *policyAPI.AutomatedException `json:"automatedException,omitempty"`
+
+	// automatedExceptionKey is where the AutomatedException of the workload lives
+	automatedExceptionKey types.NamespacedName
+	// skipped is true if the PolicyReport is out of scope
+	skipped bool
+}
+
+func (e *Explanation) decide(step string, outcome string, reason string) {
+	e.Decisions = append(e.Decisions, Decision{
+		Step:    step,
+		Outcome: outcome,
+		Reason:  reason,
+	})
+}
+
+// Explain walks the decision path of the PolicyReportReconciler for the given workload
+// among the PolicyReports, with the protected Policies allowed by its Namespace.
+// It doesn't access the API server and can be used offline.
+func (r *PolicyReportReconciler) Explain(ctx context.Context, policyReports []policyreport.PolicyReport, allowedProtectedPolicies []string, namespace string, kind string, name string) Explanation {
+	explanation := Explanation{
+		Namespace: namespace,
+		Kind:      kind,
+		Name:      name,
+	}
+
+	for _, policyReport := range policyReports {
+		if policyReport.Scope == nil || policyReport.Scope.Namespace != namespace || policyReport.Scope.Kind != kind || policyReport.Scope.Name != name {
+			continue
+		}
+
+		explanation.PolicyReport = policyReport.Name
+
+		recommendation := r.Recommend(ctx, policyReport, allowedProtectedPolicies)
+		explanation.Decisions = recommendation.Decisions
+		explanation.automatedExceptionKey = types.NamespacedName{Name: string(policyReport.Scope.UID), Namespace: recommendation.Namespace}
+		explanation.skipped = recommendation.Skipped
+
+		switch {
+		case recommendation.Skipped:
+			explanation.decide(StepException, OutcomeSkipped, "PolicyReport is out of scope, no AutomatedException is drafted")
+		case len(recommendation.FailedPolicies) == 0:
+			explanation.decide(StepException, OutcomeSkipped, "no failed Policy in warming mode, no AutomatedException is drafted")
+		default:
+			automatedException := utils.TemplateAutomatedException(policyReport, recommendation.FailedPolicies, recommendation.Namespace)
+			explanation.AutomatedException = &automatedException
+			explanation.decide(StepException, OutcomeAccepted, fmt.Sprintf("AutomatedException %s/%s is drafted for policies %v", automatedException.Namespace, automatedException.Name, recommendation.FailedPolicies))
+		}
+
+		return explanation
+	}
+
+	explanation.decide(StepScope, OutcomeSkipped, "no PolicyReport found for the workload")
+
+	return explanation
+}
+
+// ExplainHandler serves explanations for the live cluster state over HTTP.
+// The workload is selected with the namespace, kind and name query parameters.
+type ExplainHandler struct {
+	Client     client.Reader
+	Reconciler *PolicyReportReconciler
+}
+
+func (h *ExplainHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	namespace, kind, name := query.Get("namespace"), query.Get("kind"), query.Get("name")
+	if namespace == "" || kind == "" || name == "" {
+		http.Error(w, "namespace, kind and name query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	var policyReports policyreport.PolicyReportList
+	if err := h.Client.List(req.Context(), &policyReports, client.InNamespace(namespace)); err != nil {
+		http.Error(w, fmt.Sprintf("unable to list PolicyReports: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	var ns corev1.Namespace
+	if err := h.Client.Get(req.Context(), client.ObjectKey{Name: namespace}, &ns); client.IgnoreNotFound(err) != nil {
+		http.Error(w, fmt.Sprintf("unable to fetch Namespace: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	explanation := h.Reconciler.Explain(req.Context(), policyReports.Items, utils.AllowedProtectedPolicies(ns.Annotations), namespace, kind, name)
+
+	// Add the state of the existing AutomatedException, out of scope PolicyReports are already explained
+	if explanation.automatedExceptionKey.Name != "" && !explanation.skipped {
+		existing, err := getAutomatedException(req.Context(), h.Client, explanation.automatedExceptionKey.Name, explanation.automatedExceptionKey.Namespace)
+		switch {
+		case err != nil:
+			http.Error(w, fmt.Sprintf("unable to fetch AutomatedException: %s", err), http.StatusInternalServerError)
+			return
+		case existing == nil:
+			explanation.decide(StepException, OutcomeAccepted, "no AutomatedException exists yet")
+		case utils.IsApproved(*existing):
+			explanation.decide(StepException, OutcomeSkipped, fmt.Sprintf("existing AutomatedException has been approved by %s and is left untouched", existing.Annotations[utils.ApprovedByAnnotation]))
+		case existing.Annotations[utils.ExpiredAnnotation] == "true":
+			explanation.decide(StepException, OutcomeSkipped, "existing AutomatedException has expired and is only renewed on request")
+		default:
+			explanation.decide(StepException, OutcomeAccepted, fmt.Sprintf("existing AutomatedException has policies %v", existing.Spec.Policies))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(explanation); err != nil {
+		log.FromContext(req.Context()).Error(err, "unable to encode explanation")
+	}
+}
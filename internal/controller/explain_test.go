@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExplainHandler", func() {
+	explain := func(namespace string) Explanation {
+		reconciler := predicateReconciler()
+		reconciler.Client = watchClient(predicatePolicyReport("team-a", "api", "fail"), predicatePolicyReport("kube-system", "api", "fail"))
+		handler := &ExplainHandler{Client: reconciler.Client, Reconciler: reconciler}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/explain?namespace="+namespace+"&kind=Deployment&name=api", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		var explanation Explanation
+		Expect(json.NewDecoder(recorder.Body).Decode(&explanation)).To(Succeed())
+		return explanation
+	}
+
+	It("explains that a missing AutomatedException will be drafted", func() {
+		Expect(explain("team-a").Decisions).To(ContainElement(Decision{Step: StepException, Outcome: OutcomeAccepted, Reason: "no AutomatedException exists yet"}))
+	})
+
+	It("doesn't accept out of scope PolicyReports", func() {
+		decisions := explain("kube-system").Decisions
+		Expect(decisions).To(ContainElement(HaveField("Outcome", OutcomeSkipped)))
+		Expect(decisions).NotTo(ContainElement(HaveField("Outcome", OutcomeAccepted)))
+	})
+})
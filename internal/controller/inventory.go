@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// inventoryEntry is a single label set of the AutomatedExceptions inventory gauge
+type inventoryEntry struct {
+	namespace string
+	kind      string
+	policy    string
+	category  string
+}
+
+// exceptionInventory keeps the AutomatedExceptionsMetric gauge in sync with the AutomatedExceptions
+// known to the PolicyReportReconciler.
+type exceptionInventory struct {
+	mu      sync.Mutex
+	entries map[types.NamespacedName][]inventoryEntry
+	counts  map[inventoryEntry]int
+}
+
+var automatedExceptionInventory = &exceptionInventory{
+	entries: make(map[types.NamespacedName][]inventoryEntry),
+	counts:  make(map[inventoryEntry]int),
+}
+
+// Set records the policies of the AutomatedException of a workload, policies are mapped to their category.
+func (i *exceptionInventory) Set(key types.NamespacedName, namespace string, kind string, policies []string, policyCategories map[string]string) {
+	entries := make([]inventoryEntry, 0, len(policies))
+	for _, policy := range policies {
+		entries = append(entries, inventoryEntry{
+			namespace: namespace,
+			kind:      kind,
+			policy:    policy,
+			category:  policyCategories[policy],
+		})
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(key)
+	i.entries[key] = entries
+	for _, entry := range entries {
+		i.counts[entry]++
+		AutomatedExceptionsMetric.WithLabelValues(entry.namespace, entry.kind, entry.policy, entry.category).Set(float64(i.counts[entry]))
+	}
+}
+
+// Delete forgets the AutomatedException.
+func (i *exceptionInventory) Delete(key types.NamespacedName) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(key)
+}
+
+// remove decrements the gauge for the entries of the AutomatedException, the lock must be held.
+func (i *exceptionInventory) remove(key types.NamespacedName) {
+	for _, entry := range i.entries[key] {
+		i.counts[entry]--
+		if i.counts[entry] <= 0 {
+			delete(i.counts, entry)
+			AutomatedExceptionsMetric.DeleteLabelValues(entry.namespace, entry.kind, entry.policy, entry.category)
+		} else {
+			AutomatedExceptionsMetric.WithLabelValues(entry.namespace, entry.kind, entry.policy, entry.category).Set(float64(i.counts[entry]))
+		}
+	}
+	delete(i.entries, key)
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("AutomatedExceptions inventory", func() {
+	const (
+		Namespace = "inventory"
+		Kind      = "Deployment"
+		Policy    = "require-run-as-nonroot"
+		Category  = "Pod Security Standards (Restricted)"
+	)
+
+	first := types.NamespacedName{Name: "f1a3c5e7-0000-4000-8000-000000000001", Namespace: "policy-exceptions"}
+	second := types.NamespacedName{Name: "f1a3c5e7-0000-4000-8000-000000000002", Namespace: "policy-exceptions"}
+	categories := map[string]string{Policy: Category}
+
+	It("counts AutomatedExceptions by namespace, kind, policy and category", func() {
+		automatedExceptionInventory.Set(first, Namespace, Kind, []string{Policy}, categories)
+		automatedExceptionInventory.Set(second, Namespace, Kind, []string{Policy}, categories)
+		// Setting the same AutomatedException again must not count it twice
+		automatedExceptionInventory.Set(second, Namespace, Kind, []string{Policy}, categories)
+
+		Expect(testutil.ToFloat64(AutomatedExceptionsMetric.WithLabelValues(Namespace, Kind, Policy, Category))).To(Equal(2.0))
+
+		automatedExceptionInventory.Delete(first)
+		Expect(testutil.ToFloat64(AutomatedExceptionsMetric.WithLabelValues(Namespace, Kind, Policy, Category))).To(Equal(1.0))
+
+		automatedExceptionInventory.Delete(second)
+		Expect(automatedExceptionInventory.counts).NotTo(HaveKey(inventoryEntry{Namespace, Kind, Policy, Category}))
+	})
+})
@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// CircuitBreakerName is the name of the singleton ExceptionCircuitBreaker
+const CircuitBreakerName = "exception-recommender"
+
+// Limits preventing the creation of an AutomatedException, used as label of the LimitedCreationsMetric
+const (
+	LimitCircuitBreaker = "circuit_breaker"
+	LimitNamespace      = "namespace"
+	LimitPolicy         = "policy"
+)
+
+// Reasons of the CreationPaused condition
+const (
+	ReasonCreationRateExceeded = "CreationRateExceeded"
+	ReasonResumed              = "Resumed"
+)
+
+// reservationTimeout bounds how long an allowed creation is counted while it doesn't show up in the cache
+const reservationTimeout = time.Minute
+
+// CreationLimits caps the number of AutomatedExceptions managed by the recommender per workload namespace and
+// per Policy, and opens the ExceptionCircuitBreaker when more than MaxCreationsPerMinute AutomatedExceptions are
+// created within a minute. Zero values disable the corresponding limit. Only creations are limited, existing
+// AutomatedExceptions are still updated and deleted.
+type CreationLimits struct {
+	client.Client
+	Recorder              events.EventRecorder
+	MaxPerNamespace       int
+	MaxPerPolicy          int
+	MaxCreationsPerMinute int
+	// Replicas returns the number of replicas creating AutomatedExceptions, which share the MaxCreationsPerMinute
+	// as each replica only counts its own creations. A single replica when unset.
+	Replicas func() int
+
+	mu        sync.Mutex
+	creations []time.Time
+	// breakerOpen is the state of the circuit breaker last seen by Allow, on every replica
+	breakerOpen bool
+	// reserved holds the creations allowed but not yet in the cache, so concurrent reconciles don't exceed the caps
+	reserved map[client.ObjectKey]reservation
+}
+
+// reservation is a creation allowed by the CreationLimits
+type reservation struct {
+	namespace string
+	policies  []string
+	at        time.Time
+}
+
+// Allow returns the limit preventing the creation of the AutomatedException for the policies of a workload
+// in the namespace, or an empty string if it may be created. An allowed creation is reserved until the
+// AutomatedException shows up in the cache, or until Release is called when it wasn't created.
+func (l *CreationLimits) Allow(ctx context.Context, key client.ObjectKey, namespace string, policies []string) (string, error) {
+	var breaker recommenderAPI.ExceptionCircuitBreaker
+	if err := l.Get(ctx, client.ObjectKey{Name: CircuitBreakerName}, &breaker); client.IgnoreNotFound(err) != nil {
+		return "", err
+	}
+	if l.observeBreaker(breaker.Status.Open) {
+		return LimitCircuitBreaker, nil
+	}
+	if l.MaxPerNamespace <= 0 && l.MaxPerPolicy <= 0 {
+		return "", nil
+	}
+
+	var automatedExceptions policyAPI.AutomatedExceptionList
+	if err := l.List(ctx, &automatedExceptions, client.MatchingLabels{utils.AppLabelName: utils.ComponentName}); err != nil {
+		return "", err
+	}
+
+	// The check and the reservation are serialized, the reservations not yet in the cache are counted too
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	namespaceCount := 0
+	policyCounts := make(map[string]int)
+	for _, automatedException := range automatedExceptions.Items {
+		existing := client.ObjectKeyFromObject(&automatedException)
+		delete(l.reserved, existing)
+		if existing == key {
+			continue
+		}
+		if automatedException.Labels[utils.NamespaceLabelName] == namespace {
+			namespaceCount++
+		}
+		for _, policy := range automatedException.Spec.Policies {
+			policyCounts[policy]++
+		}
+	}
+	for reserved, r := range l.reserved {
+		if now.Sub(r.at) >= reservationTimeout {
+			delete(l.reserved, reserved)
+			continue
+		}
+		if reserved == key {
+			continue
+		}
+		if r.namespace == namespace {
+			namespaceCount++
+		}
+		for _, policy := range r.policies {
+			policyCounts[policy]++
+		}
+	}
+
+	if l.MaxPerNamespace > 0 && namespaceCount >= l.MaxPerNamespace {
+		return LimitNamespace, nil
+	}
+	if l.MaxPerPolicy > 0 {
+		for _, policy := range policies {
+			if policyCounts[policy] >= l.MaxPerPolicy {
+				return LimitPolicy, nil
+			}
+		}
+	}
+
+	if l.reserved == nil {
+		l.reserved = make(map[client.ObjectKey]reservation)
+	}
+	l.reserved[key] = reservation{namespace: namespace, policies: policies, at: now}
+	return "", nil
+}
+
+// Release forgets the reservation of an AutomatedException which wasn't created.
+func (l *CreationLimits) Release(key client.ObjectKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.reserved, key)
+}
+
+// Created records the creation of an AutomatedException and opens the circuit breaker
+// once the creation rate is exceeded.
+func (l *CreationLimits) Created(ctx context.Context, now time.Time) error {
+	if l.MaxCreationsPerMinute <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	l.creations = append(l.creations, now)
+	// Forget the creations older than a minute
+	for len(l.creations) > 0 && now.Sub(l.creations[0]) >= time.Minute {
+		l.creations = l.creations[1:]
+	}
+	maxCreations := l.maxCreationsPerMinute()
+	exceeded := len(l.creations) > maxCreations
+	l.mu.Unlock()
+
+	if !exceeded {
+		return nil
+	}
+
+	return l.trip(ctx, now, fmt.Sprintf("More than %d AutomatedExceptions were created within a minute by this replica, creation is paused until the %s annotation is set", maxCreations, recommenderAPI.ResumeAnnotation))
+}
+
+// maxCreationsPerMinute returns the share of the MaxCreationsPerMinute of the replica, at least one.
+func (l *CreationLimits) maxCreationsPerMinute() int {
+	replicas := 1
+	if l.Replicas != nil {
+		replicas = max(l.Replicas(), 1)
+	}
+	return max(l.MaxCreationsPerMinute/replicas, 1)
+}
+
+// observeBreaker records the state of the circuit breaker and forgets the recorded creations once it was
+// closed, so that every replica starts a new creation window on resume and not only the one closing it.
+func (l *CreationLimits) observeBreaker(open bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.breakerOpen && !open {
+		l.creations = nil
+	}
+	l.breakerOpen = open
+	return open
+}
+
+// trip opens the circuit breaker, creating it if needed
+func (l *CreationLimits) trip(ctx context.Context, now time.Time, message string) error {
+	var breaker recommenderAPI.ExceptionCircuitBreaker
+	err := l.Get(ctx, client.ObjectKey{Name: CircuitBreakerName}, &breaker)
+	switch {
+	case apierrors.IsNotFound(err):
+		breaker = recommenderAPI.ExceptionCircuitBreaker{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   CircuitBreakerName,
+				Labels: map[string]string{utils.AppLabelName: utils.ComponentName},
+			},
+		}
+		if err := l.Create(ctx, &breaker); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case breaker.Status.Open:
+		return nil
+	}
+
+	patch := client.MergeFrom(breaker.DeepCopy())
+	breaker.Status.Open = true
+	breaker.Status.TrippedAt = &metav1.Time{Time: now}
+	meta.SetStatusCondition(&breaker.Status.Conditions, metav1.Condition{
+		Type:    recommenderAPI.CreationPausedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonCreationRateExceeded,
+		Message: message,
+	})
+	if err := l.Status().Patch(ctx, &breaker, patch); err != nil {
+		return err
+	}
+
+	CircuitBreakerOpenMetric.Set(1)
+	log.FromContext(ctx).Info("Opened the circuit breaker, creation of AutomatedExceptions is paused", "maxCreationsPerMinute", l.MaxCreationsPerMinute)
+	if l.Recorder != nil {
+		l.Recorder.Eventf(&breaker, nil, corev1.EventTypeWarning, "CircuitBreakerOpened", "Trip", message)
+	}
+
+	return nil
+}
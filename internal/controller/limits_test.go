@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("Creation limits", func() {
+	ctx := context.Background()
+	now := time.Now()
+
+	automatedException := func(name string, namespace string, policies ...string) client.Object {
+		return &policyAPI.AutomatedException{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "policy-exceptions",
+				Labels:    map[string]string{utils.AppLabelName: utils.ComponentName, utils.NamespaceLabelName: namespace},
+			},
+			Spec: policyAPI.AutomatedExceptionSpec{Policies: policies},
+		}
+	}
+	newClient := func(objects ...client.Object) client.Client {
+		scheme := runtime.NewScheme()
+		Expect(policyAPI.AddToScheme(scheme)).To(Succeed())
+		Expect(recommenderAPI.AddToScheme(scheme)).To(Succeed())
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+			WithStatusSubresource(&recommenderAPI.ExceptionCircuitBreaker{}).Build()
+	}
+	key := func(name string) client.ObjectKey {
+		return client.ObjectKey{Namespace: "policy-exceptions", Name: name}
+	}
+	getBreaker := func(c client.Client) recommenderAPI.ExceptionCircuitBreaker {
+		var breaker recommenderAPI.ExceptionCircuitBreaker
+		Expect(c.Get(ctx, client.ObjectKey{Name: CircuitBreakerName}, &breaker)).To(Succeed())
+		return breaker
+	}
+
+	It("caps the AutomatedExceptions per namespace", func() {
+		limits := &CreationLimits{
+			Client:          newClient(automatedException("api", "team-a", "require-labels"), automatedException("web", "team-a", "require-labels")),
+			MaxPerNamespace: 2,
+		}
+
+		Expect(limits.Allow(ctx, key("new"), "team-a", []string{"require-labels"})).To(Equal(LimitNamespace))
+		Expect(limits.Allow(ctx, key("new"), "team-b", []string{"require-labels"})).To(BeEmpty())
+	})
+
+	It("ignores the AutomatedExceptions not managed by the recommender", func() {
+		unmanaged := automatedException("web", "team-a", "require-labels")
+		unmanaged.SetLabels(map[string]string{utils.NamespaceLabelName: "team-a"})
+		limits := &CreationLimits{
+			Client:          newClient(automatedException("api", "team-a", "require-labels"), unmanaged),
+			MaxPerNamespace: 2,
+		}
+
+		Expect(limits.Allow(ctx, key("new"), "team-a", []string{"require-labels"})).To(BeEmpty())
+	})
+
+	It("counts the creations allowed but not yet cached", func() {
+		limits := &CreationLimits{
+			Client:          newClient(automatedException("api", "team-a", "require-labels")),
+			MaxPerNamespace: 2,
+		}
+
+		Expect(limits.Allow(ctx, key("web"), "team-a", []string{"require-labels"})).To(BeEmpty())
+		Expect(limits.Allow(ctx, key("db"), "team-a", []string{"require-labels"})).To(Equal(LimitNamespace))
+		// Retrying the same creation doesn't count its own reservation
+		Expect(limits.Allow(ctx, key("web"), "team-a", []string{"require-labels"})).To(BeEmpty())
+
+		limits.Release(key("web"))
+		Expect(limits.Allow(ctx, key("db"), "team-a", []string{"require-labels"})).To(BeEmpty())
+	})
+
+	It("caps the AutomatedExceptions per policy", func() {
+		limits := &CreationLimits{
+			Client:       newClient(automatedException("api", "team-a", "require-labels"), automatedException("web", "team-b", "require-labels")),
+			MaxPerPolicy: 2,
+		}
+
+		Expect(limits.Allow(ctx, key("new"), "team-c", []string{"require-run-as-nonroot", "require-labels"})).To(Equal(LimitPolicy))
+		Expect(limits.Allow(ctx, key("new"), "team-c", []string{"require-run-as-nonroot"})).To(BeEmpty())
+	})
+
+	It("opens the circuit breaker when the creation rate is exceeded", func() {
+		recorder := events.NewFakeRecorder(10)
+		limits := &CreationLimits{Client: newClient(), Recorder: recorder, MaxCreationsPerMinute: 3}
+
+		// Creations older than a minute are forgotten
+		Expect(limits.Created(ctx, now.Add(-2*time.Minute))).To(Succeed())
+		Expect(limits.Created(ctx, now.Add(-time.Second))).To(Succeed())
+		Expect(limits.Created(ctx, now)).To(Succeed())
+		Expect(limits.Created(ctx, now)).To(Succeed())
+		Expect(limits.Allow(ctx, key("new"), "team-a", nil)).To(BeEmpty())
+
+		Expect(limits.Created(ctx, now)).To(Succeed())
+
+		breaker := getBreaker(limits.Client)
+		Expect(breaker.Status.Open).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(breaker.Status.Conditions, recommenderAPI.CreationPausedCondition)).To(BeTrue())
+		Expect(recorder.Events).To(Receive(ContainSubstring("CircuitBreakerOpened")))
+		Expect(limits.Allow(ctx, key("new"), "team-a", nil)).To(Equal(LimitCircuitBreaker))
+	})
+
+	It("shares the creation rate between the replicas", func() {
+		limits := &CreationLimits{Client: newClient(), MaxCreationsPerMinute: 4, Replicas: func() int { return 2 }}
+
+		Expect(limits.Created(ctx, now)).To(Succeed())
+		Expect(limits.Created(ctx, now)).To(Succeed())
+		Expect(limits.Allow(ctx, key("new"), "team-a", nil)).To(BeEmpty())
+		Expect(limits.Created(ctx, now)).To(Succeed())
+		Expect(limits.Allow(ctx, key("new"), "team-a", nil)).To(Equal(LimitCircuitBreaker))
+	})
+
+	It("resumes creation with the resume annotation", func() {
+		limits := &CreationLimits{Client: newClient(), MaxCreationsPerMinute: 1}
+		Expect(limits.Created(ctx, now)).To(Succeed())
+		Expect(limits.Created(ctx, now)).To(Succeed())
+
+		breaker := getBreaker(limits.Client)
+		breaker.Annotations = map[string]string{recommenderAPI.ResumeAnnotation: "true"}
+		Expect(limits.Update(ctx, &breaker)).To(Succeed())
+
+		reconciler := &CircuitBreakerReconciler{Client: limits.Client}
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: CircuitBreakerName}})
+		Expect(err).NotTo(HaveOccurred())
+
+		breaker = getBreaker(limits.Client)
+		Expect(breaker.Status.Open).To(BeFalse())
+		Expect(breaker.Annotations).NotTo(HaveKey(recommenderAPI.ResumeAnnotation))
+		Expect(meta.FindStatusCondition(breaker.Status.Conditions, recommenderAPI.CreationPausedCondition).Reason).To(Equal(ReasonResumed))
+		Expect(limits.Allow(ctx, key("new"), "team-a", nil)).To(BeEmpty())
+	})
+
+	It("starts a new creation window on every replica once the circuit breaker is closed", func() {
+		leader := &CreationLimits{Client: newClient(), MaxCreationsPerMinute: 2}
+		replica := &CreationLimits{Client: leader.Client, MaxCreationsPerMinute: 2}
+		Expect(replica.Created(ctx, now)).To(Succeed())
+		Expect(replica.Created(ctx, now)).To(Succeed())
+		Expect(leader.Created(ctx, now)).To(Succeed())
+		Expect(leader.Created(ctx, now)).To(Succeed())
+		Expect(leader.Created(ctx, now)).To(Succeed())
+		Expect(replica.Allow(ctx, key("new"), "team-a", nil)).To(Equal(LimitCircuitBreaker))
+
+		breaker := getBreaker(leader.Client)
+		breaker.Annotations = map[string]string{recommenderAPI.ResumeAnnotation: "true"}
+		Expect(leader.Update(ctx, &breaker)).To(Succeed())
+		reconciler := &CircuitBreakerReconciler{Client: leader.Client}
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: CircuitBreakerName}})
+		Expect(err).NotTo(HaveOccurred())
+
+		// The replica which didn't close the circuit breaker doesn't trip it again with its previous creations
+		Expect(replica.Allow(ctx, key("new"), "team-a", nil)).To(BeEmpty())
+		Expect(replica.Created(ctx, now)).To(Succeed())
+		Expect(getBreaker(leader.Client).Status.Open).To(BeFalse())
+	})
+})
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+	"github.com/giantswarm/exception-recommender/tests"
+)
+
+// loadStats counts the reconciliations and API calls of a simulated run
+type loadStats struct {
+	reconciles int
+	reads      int
+	writes     int
+}
+
+// simulateLoad reconciles the PolicyReports during a simulated hour, in which a tenth of the PolicyReports change
+// after half an hour. periodic models the former behaviour, requeueing every PolicyReport after DefaultRequeueDuration.
+func simulateLoad(policyReports int, periodic bool) loadStats {
+	ctx := context.Background()
+	var stats loadStats
+
+	objects := make([]client.Object, 0, policyReports)
+	for i := 0; i < policyReports; i++ {
+		objects = append(objects, predicatePolicyReport("team-a", fmt.Sprintf("app-%d", i), "fail"))
+	}
+	c := watchClient(objects...)
+	reconciler := predicateReconciler()
+	reconciler.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			stats.reads++
+			return c.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			stats.reads++
+			return c.List(ctx, list, opts...)
+		},
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			stats.writes++
+			return c.Create(ctx, obj, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			stats.writes++
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			stats.writes++
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+
+	type item struct {
+		at  time.Duration
+		key types.NamespacedName
+	}
+	// The empty key marks the change of the PolicyReports
+	queue := []item{{at: 30 * time.Minute}}
+	for _, object := range objects {
+		queue = append(queue, item{key: client.ObjectKeyFromObject(object)})
+	}
+
+	for len(queue) > 0 {
+		// Pop the earliest item
+		next := 0
+		for i := range queue {
+			if queue[i].at < queue[next].at {
+				next = i
+			}
+		}
+		current := queue[next]
+		queue = append(queue[:next], queue[next+1:]...)
+		if current.at > time.Hour {
+			continue
+		}
+
+		// A tenth of the PolicyReports pass after half an hour, which triggers their reconciliation
+		if current.key.Name == "" {
+			for i := 0; i < policyReports/10; i++ {
+				var policyReport policyreport.PolicyReport
+				key := types.NamespacedName{Namespace: "team-a", Name: fmt.Sprintf("app-%d", i)}
+				Expect(c.Get(ctx, key, &policyReport)).To(Succeed())
+				policyReport.Results[0].Result = "pass"
+				Expect(c.Update(ctx, &policyReport)).To(Succeed())
+				queue = append(queue, item{at: current.at, key: key})
+			}
+			continue
+		}
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: current.key})
+		Expect(err).NotTo(HaveOccurred())
+		stats.reconciles++
+
+		switch {
+		case result.RequeueAfter > 0:
+			queue = append(queue, item{at: current.at + result.RequeueAfter, key: current.key})
+		case periodic:
+			queue = append(queue, item{at: current.at + DefaultRequeueDuration, key: current.key})
+		}
+	}
+
+	return stats
+}
+
+var _ = Describe("Load", func() {
+	It("reconciles PolicyReports on changes only", func() {
+		const policyReports = 200
+
+		periodic := simulateLoad(policyReports, true)
+		eventDriven := simulateLoad(policyReports, false)
+		AddReportEntry("PolicyReport reconciliations over an hour", fmt.Sprintf(
+			"periodic: %d reconciles, %d reads, %d writes; event-driven: %d reconciles, %d reads, %d writes",
+			periodic.reconciles, periodic.reads, periodic.writes, eventDriven.reconciles, eventDriven.reads, eventDriven.writes))
+
+		// Every PolicyReport is reconciled once, and once more if it changed
+		Expect(eventDriven.reconciles).To(Equal(policyReports + policyReports/10))
+		Expect(periodic.reconciles).To(BeNumerically(">=", 12*policyReports))
+		Expect(eventDriven.writes * 5).To(BeNumerically("<", periodic.writes))
+		Expect(eventDriven.reads * 5).To(BeNumerically("<", periodic.reads))
+	})
+})
+
+var _ = Describe("PolicyReport controller under load", Ordered, func() {
+	const (
+		loadNamespace  = "load"
+		loadPolicy     = "load-require-run-as-nonroot"
+		loadReports    = 2000
+		loadTimeout    = 5 * time.Minute
+		loadInterval   = time.Second
+		loadConcurrent = 20
+	)
+
+	// countAutomatedExceptions counts the AutomatedExceptions of the workloads of the load namespace
+	countAutomatedExceptions := func() int {
+		var automatedExceptions policyAPI.AutomatedExceptionList
+		Expect(k8sClient.List(ctx, &automatedExceptions, client.InNamespace(destinationNamespace),
+			client.MatchingLabels{utils.NamespaceLabelName: loadNamespace})).To(Succeed())
+		return len(automatedExceptions.Items)
+	}
+
+	BeforeAll(func() {
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: loadNamespace}})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &policyAPI.PolicyManifest{
+			ObjectMeta: metav1.ObjectMeta{Name: loadPolicy},
+			Spec: policyAPI.PolicyManifestSpec{
+				Mode:                ManifestExpectedMode,
+				Args:                []string{},
+				Exceptions:          []policyAPI.Target{},
+				AutomatedExceptions: []policyAPI.Target{},
+			},
+		})).To(Succeed())
+	})
+
+	It("drafts an AutomatedException for thousands of PolicyReports within the write rate", func() {
+		start := time.Now()
+
+		// Create the synthetic PolicyReports in parallel
+		names := make(chan string)
+		done := make(chan struct{})
+		for i := 0; i < loadConcurrent; i++ {
+			go func() {
+				defer GinkgoRecover()
+				for name := range names {
+					uid := tests.GenerateGUID(name)
+					Expect(k8sClient.Create(ctx, &policyreport.PolicyReport{
+						ObjectMeta: metav1.ObjectMeta{Name: uid, Namespace: loadNamespace},
+						Scope:      &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: name, Namespace: loadNamespace, UID: types.UID(uid)},
+						Results: []policyreport.PolicyReportResult{
+							{Policy: loadPolicy, Rule: "run-as-nonroot", Category: targetCategories[0], Result: "fail"},
+						},
+					})).To(Succeed())
+				}
+				done <- struct{}{}
+			}()
+		}
+		for i := 0; i < loadReports; i++ {
+			names <- fmt.Sprintf("app-%d", i)
+		}
+		close(names)
+		for i := 0; i < loadConcurrent; i++ {
+			<-done
+		}
+		created := time.Since(start)
+
+		Eventually(countAutomatedExceptions, loadTimeout, loadInterval).Should(Equal(loadReports))
+		elapsed := time.Since(start)
+		AddReportEntry("PolicyReports reconciled under load", fmt.Sprintf(
+			"%d PolicyReports created in %s, AutomatedExceptions drafted in %s (%.0f/s) with %d workers and %.0f writes/s",
+			loadReports, created.Round(time.Millisecond), elapsed.Round(time.Millisecond), float64(loadReports)/elapsed.Seconds(),
+			policyReportWorkers, exceptionWriteQPS))
+
+		// The write limiter holds back the writes beyond the burst
+		Expect(elapsed.Seconds()).To(BeNumerically(">=", float64(loadReports-exceptionWriteBurst)/float64(exceptionWriteQPS)))
+	})
+
+	It("deletes the AutomatedExceptions of the deleted PolicyReports", func() {
+		Expect(k8sClient.DeleteAllOf(ctx, &policyreport.PolicyReport{}, client.InNamespace(loadNamespace))).To(Succeed())
+		Eventually(countAutomatedExceptions, loadTimeout, loadInterval).Should(BeZero())
+	})
+
+	AfterAll(func() {
+		Expect(k8sClient.Delete(ctx, &policyAPI.PolicyManifest{ObjectMeta: metav1.ObjectMeta{Name: loadPolicy}})).To(Succeed())
+	})
+})
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DebugLevel is the verbosity of the messages logged on every reconciliation, e.g. unchanged AutomatedExceptions.
+// It is enabled with --zap-log-level=debug.
+const DebugLevel = 1
+
+// logConstructor builds the logger of every reconcile request from the reconciler logger, or from the manager
+// logger if none is set. The controller adds the reconcile ID before passing it through the context.
+func logConstructor(base logr.Logger, mgr ctrl.Manager, name string, key string) func(*reconcile.Request) logr.Logger {
+	if base.GetSink() == nil {
+		base = mgr.GetLogger().WithName(name)
+	}
+
+	return func(req *reconcile.Request) logr.Logger {
+		if req == nil {
+			return base
+		}
+		return base.WithValues(key, req.NamespacedName)
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// DefaultManifestBackoff is the first delay before retrying a Policy without PolicyManifest
+const DefaultManifestBackoff = 15 * time.Second
+
+// ManifestBackoff spaces out the retries of the PolicyReports failing Policies without PolicyManifest.
+// The delay follows the Strategy on every retry of the Policy, whichever PolicyReport retries it,
+// so that all the PolicyReports of a Policy are retried together.
+type ManifestBackoff struct {
+	Strategy utils.ExponentialRequeue
+
+	mu       sync.Mutex
+	policies map[string]*manifestRetry
+}
+
+// manifestRetry is the backoff state of a Policy without PolicyManifest
+type manifestRetry struct {
+	retries int
+	retryAt time.Time
+}
+
+// NewManifestBackoff returns a ManifestBackoff following the strategy.
+func NewManifestBackoff(strategy utils.ExponentialRequeue) *ManifestBackoff {
+	return &ManifestBackoff{
+		Strategy: strategy,
+		policies: make(map[string]*manifestRetry),
+	}
+}
+
+// Missing records that the Policy has no PolicyManifest and returns the delay until it is retried, before jitter.
+func (b *ManifestBackoff) Missing(policy string, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	retry, ok := b.policies[policy]
+	if !ok {
+		retry = &manifestRetry{}
+		b.policies[policy] = retry
+		MissingPolicyManifestsMetric.WithLabelValues(policy).Set(1)
+	}
+	if now.Before(retry.retryAt) {
+		// Another PolicyReport already started the current delay
+		return retry.retryAt.Sub(now)
+	}
+
+	// The PolicyReports are jittered on their own
+	delay := b.Strategy.Backoff(retry.retries)
+	retry.retries++
+	retry.retryAt = now.Add(delay)
+
+	return delay
+}
+
+// Policies returns the Policies without PolicyManifest.
+func (b *ManifestBackoff) Policies() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	policies := make([]string, 0, len(b.policies))
+	for policy := range b.policies {
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// Forget forgets the Policy once its PolicyManifest exists, or once no PolicyReport references it any more.
+func (b *ManifestBackoff) Forget(policy string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.policies[policy]; ok {
+		delete(b.policies, policy)
+		MissingPolicyManifestsMetric.DeleteLabelValues(policy)
+	}
+}
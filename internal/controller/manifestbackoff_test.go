@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("ManifestBackoff", func() {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	It("doubles the delay of a Policy up to the maximum", func() {
+		backoff := NewManifestBackoff(utils.ExponentialRequeue{Initial: 15 * time.Second, Max: time.Minute})
+		var delays []time.Duration
+		at := now
+		for i := 0; i < 5; i++ {
+			delay := backoff.Missing("backoff-doubling", at)
+			delays = append(delays, delay)
+			at = at.Add(delay)
+		}
+		Expect(delays).To(Equal([]time.Duration{15 * time.Second, 30 * time.Second, time.Minute, time.Minute, time.Minute}))
+	})
+
+	It("retries the PolicyReports of a Policy together", func() {
+		backoff := NewManifestBackoff(utils.ExponentialRequeue{Initial: 15 * time.Second, Max: time.Minute})
+		Expect(backoff.Missing("backoff-shared", now)).To(Equal(15 * time.Second))
+		// Other PolicyReports wait for the same retry
+		Expect(backoff.Missing("backoff-shared", now.Add(5*time.Second))).To(Equal(10 * time.Second))
+		Expect(backoff.Missing("backoff-other", now.Add(5*time.Second))).To(Equal(15 * time.Second))
+		Expect(backoff.Missing("backoff-shared", now.Add(15*time.Second))).To(Equal(30 * time.Second))
+	})
+
+	It("exposes the Policies until their PolicyManifest is found", func() {
+		backoff := NewManifestBackoff(utils.ExponentialRequeue{Initial: 15 * time.Second, Max: time.Minute})
+		backoff.Missing("backoff-found", now)
+		backoff.Missing("backoff-found", now.Add(time.Minute))
+		Expect(testutil.ToFloat64(MissingPolicyManifestsMetric.WithLabelValues("backoff-found"))).To(Equal(1.0))
+
+		backoff.Forget("backoff-found")
+		Expect(MissingPolicyManifestsMetric.DeleteLabelValues("backoff-found")).To(BeFalse())
+		Expect(backoff.Missing("backoff-found", now.Add(2*time.Minute))).To(Equal(15 * time.Second))
+		backoff.Forget("backoff-found")
+	})
+
+	It("retries PolicyReports once the PolicyManifest is created", func() {
+		ctx := context.Background()
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		recorder := events.NewFakeRecorder(10)
+		reconciler := predicateReconciler()
+		reconciler.PolicyManifestCache = NewPolicyManifestCache()
+		reconciler.ManifestBackoff = NewManifestBackoff(utils.ExponentialRequeue{Initial: time.Minute, Max: time.Hour})
+		reconciler.Recorder = recorder
+		reconciler.Client = watchClient(policyReport)
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)})
+		Expect(err).NotTo(HaveOccurred())
+		// Retries are only delayed by the jitter
+		Expect(result.RequeueAfter).To(BeNumerically(">=", time.Minute))
+		Expect(result.RequeueAfter).To(BeNumerically("<=", time.Minute+6*time.Second))
+		Expect(recorder.Events).To(Receive(ContainSubstring("ManifestMissing")))
+		Expect(testutil.ToFloat64(MissingPolicyManifestsMetric.WithLabelValues("require-run-as-nonroot"))).To(Equal(1.0))
+
+		requests := reconciler.policyManifestRequests(ctx, &policyAPI.PolicyManifest{ObjectMeta: metav1.ObjectMeta{Name: "require-run-as-nonroot"}})
+		Expect(requests).To(ConsistOf(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)}))
+		Expect(MissingPolicyManifestsMetric.DeleteLabelValues("require-run-as-nonroot")).To(BeFalse())
+	})
+
+	It("forgets the Policies once no PolicyReport references them", func() {
+		ctx := context.Background()
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		reconciler := predicateReconciler()
+		reconciler.PolicyManifestCache = NewPolicyManifestCache()
+		reconciler.ManifestBackoff = NewManifestBackoff(utils.ExponentialRequeue{Initial: time.Minute, Max: time.Hour})
+		reconciler.Client = watchClient(policyReport)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.ManifestBackoff.Policies()).To(ConsistOf("require-run-as-nonroot"))
+
+		Expect(reconciler.Delete(ctx, policyReport)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.ManifestBackoff.Policies()).To(BeEmpty())
+		Expect(MissingPolicyManifestsMetric.DeleteLabelValues("require-run-as-nonroot")).To(BeFalse())
+	})
+})
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"sync"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+// PolicyManifestCache holds the PolicyManifests by Policy name. It is written by the PolicyManifestReconciler and
+// read concurrently by the PolicyReport workers and the explain endpoint.
+type PolicyManifestCache struct {
+	mu        sync.RWMutex
+	manifests map[string]policyAPI.PolicyManifest
+}
+
+// NewPolicyManifestCache returns a cache holding the PolicyManifests.
+func NewPolicyManifestCache(policyManifests ...policyAPI.PolicyManifest) *PolicyManifestCache {
+	c := &PolicyManifestCache{manifests: make(map[string]policyAPI.PolicyManifest, len(policyManifests))}
+	for _, policyManifest := range policyManifests {
+		c.manifests[policyManifest.Name] = policyManifest
+	}
+	return c
+}
+
+// Get returns the PolicyManifest of the Policy and whether it is cached.
+func (c *PolicyManifestCache) Get(policy string) (policyAPI.PolicyManifest, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	policyManifest, ok := c.manifests[policy]
+	return policyManifest, ok
+}
+
+// Set caches the PolicyManifest under its name.
+func (c *PolicyManifestCache) Set(policyManifest policyAPI.PolicyManifest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.manifests == nil {
+		c.manifests = make(map[string]policyAPI.PolicyManifest)
+	}
+	c.manifests[policyManifest.Name] = policyManifest
+}
+
+// Delete forgets the PolicyManifest of the Policy.
+func (c *PolicyManifestCache) Delete(policy string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.manifests, policy)
+}
+
+// Len returns the number of cached PolicyManifests.
+func (c *PolicyManifestCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.manifests)
+}
+
+// Modes counts the cached PolicyManifests by mode.
+func (c *PolicyManifestCache) Modes() map[string]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	modes := make(map[string]int)
+	for _, policyManifest := range c.manifests {
+		modes[policyManifest.Spec.Mode]++
+	}
+	return modes
+}
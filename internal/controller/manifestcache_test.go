@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+var _ = Describe("PolicyManifestCache", func() {
+	It("caches PolicyManifests by name", func() {
+		cache := NewPolicyManifestCache()
+		Expect(GetPolicyManifestMode("require-labels", cache)).To(BeEmpty())
+
+		cache.Set(policyAPI.PolicyManifest{ObjectMeta: metav1.ObjectMeta{Name: "require-labels"}, Spec: policyAPI.PolicyManifestSpec{Mode: "audit"}})
+		Expect(GetPolicyManifestMode("require-labels", cache)).To(Equal("audit"))
+		Expect(cache.Modes()).To(Equal(map[string]int{"audit": 1}))
+
+		cache.Delete("require-labels")
+		Expect(cache.Len()).To(BeZero())
+	})
+
+	It("is safe for concurrent reconcilers", func() {
+		cache := &PolicyManifestCache{}
+		var wg sync.WaitGroup
+		for worker := range 4 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := range 1000 {
+					name := fmt.Sprintf("policy-%d", i%10)
+					cache.Set(policyAPI.PolicyManifest{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: policyAPI.PolicyManifestSpec{Mode: ManifestExpectedMode}})
+					if i%3 == worker%3 {
+						cache.Delete(name)
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := range 1000 {
+					GetPolicyManifestMode(fmt.Sprintf("policy-%d", i%10), cache)
+					cache.Modes()
+				}
+			}()
+		}
+		wg.Wait()
+		Expect(cache.Len()).To(BeNumerically("<=", 10))
+	})
+})
@@ -23,13 +23,14 @@ import (
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
-
-	utils "github.com/giantswarm/exception-recommender/internal/utils"
 )
 
 // PolicyManifestReconciler reconciles a PolicyManifest object
@@ -37,41 +38,52 @@ type PolicyManifestReconciler struct {
 	client.Client
 	Scheme              *runtime.Scheme
 	Log                 logr.Logger
-	PolicyManifestCache map[string]policyAPI.PolicyManifest
-	MaxJitterPercent    int
+	PolicyManifestCache *PolicyManifestCache
+	// ModeChanges receives the PolicyManifests whose mode changed once the cache is updated, when set
+	ModeChanges chan<- event.GenericEvent
+	// Sharded runs the controller on every replica instead of the leader only, as every shard needs the
+	// PolicyManifests
+	Sharded bool
 }
 
 func (r *PolicyManifestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
-	_ = r.Log.WithValues("policymanifest", req.NamespacedName)
+	logger := log.FromContext(ctx)
 	reconcilerResourceType := "PolicyManifest"
 
 	var policyManifest policyAPI.PolicyManifest
 
-	if err := r.Get(ctx, req.NamespacedName, &policyManifest); err != nil {
-		if !errors.IsNotFound(err) {
-			// Error fetching the report
-			log.Log.Error(err, "unable to fetch PolicyManifest")
-			// Metric for failed PolicyManifest reconciliation
-			ReconciliationFailuresMetric.WithLabelValues(reconcilerResourceType).Inc()
-		}
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+	err := r.Get(ctx, req.NamespacedName, &policyManifest)
+	if err != nil && !errors.IsNotFound(err) {
+		// Error fetching the report
+		logger.Error(err, "unable to fetch PolicyManifest")
+		// Metric for failed PolicyManifest reconciliation
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
 	}
 
-	// Delete manifest from cache if it is being deleted
-	if !policyManifest.DeletionTimestamp.IsZero() {
-		delete(r.PolicyManifestCache, policyManifest.Name)
+	previousMode := GetPolicyManifestMode(req.Name, r.PolicyManifestCache)
+	// Delete manifest from cache if it is deleted or being deleted
+	if errors.IsNotFound(err) || !policyManifest.DeletionTimestamp.IsZero() {
+		r.PolicyManifestCache.Delete(req.Name)
 	} else {
 		// Add the PolicyManifest to the cache
-		r.PolicyManifestCache[policyManifest.Name] = policyManifest
+		r.PolicyManifestCache.Set(policyManifest)
+	}
+	// Reconcile the PolicyReports of the Policy with the updated cache
+	notifyModeChange(r.ModeChanges, req.Name, previousMode, GetPolicyManifestMode(req.Name, r.PolicyManifestCache))
+
+	// Count cached PolicyManifests by mode
+	CachedPolicyManifestsMetric.Reset()
+	for mode, count := range r.PolicyManifestCache.Modes() {
+		CachedPolicyManifestsMetric.WithLabelValues(mode).Set(float64(count))
 	}
 
-	return utils.JitterRequeue(DefaultRequeueDuration, r.MaxJitterPercent, r.Log), nil
+	return ctrl.Result{}, nil
 }
 
-func GetPolicyManifestMode(policyName string, cache map[string]policyAPI.PolicyManifest) string {
+func GetPolicyManifestMode(policyName string, cache *PolicyManifestCache) string {
 	// Get the PolicyManifest from the cache
-	policyManifest := cache[policyName]
+	policyManifest, _ := cache.Get(policyName)
 
 	// Check if PolicyManifest is not empty
 	if reflect.DeepEqual(policyManifest, policyAPI.PolicyManifest{}) {
@@ -83,7 +95,13 @@ func GetPolicyManifestMode(policyName string, cache map[string]policyAPI.PolicyM
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PolicyManifestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	var options crcontroller.Options
+	if r.Sharded {
+		options.NeedLeaderElection = ptr.To(false)
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&policyAPI.PolicyManifest{}).
+		WithOptions(options).
+		WithLogConstructor(logConstructor(r.Log, mgr, "policymanifest", "policymanifest")).
 		Complete(r)
 }
@@ -19,21 +19,34 @@ package controller
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
 
+	"github.com/giantswarm/exception-recommender/internal/audit"
+	"github.com/giantswarm/exception-recommender/internal/tracing"
 	utils "github.com/giantswarm/exception-recommender/internal/utils"
 )
 
@@ -42,6 +55,16 @@ const (
 	ManifestExpectedMode          = "warming"
 )
 
+// preservedAnnotations are written by the reviewers and the approval controller, they are kept when the
+// AutomatedException is updated.
+var preservedAnnotations = []string{
+	utils.ApprovedByAnnotation,
+	utils.ApprovedAtAnnotation,
+	utils.ApprovalJustificationAnnotation,
+	utils.ApprovalStatusAnnotation,
+	utils.RenewRequestAnnotation,
+}
+
 // PolicyReportReconciler reconciles a PolicyReport object
 type PolicyReportReconciler struct {
 	client.Client
@@ -49,134 +72,599 @@ type PolicyReportReconciler struct {
 	Log                  logr.Logger
 	ExcludeNamespaces    []string
 	DestinationNamespace string
-	PolicyManifestCache  map[string]policyAPI.PolicyManifest
+	PolicyManifestCache  *PolicyManifestCache
 	TargetWorkloads      []string
 	TargetCategories     []string
-	MaxJitterPercent     int
+	ExceptionTTL         utils.ExceptionTTL
+	DeletionGrace        utils.DeletionGrace
+	// ProtectedPolicies always require a human-authored exception, unless allowed by their Namespace
+	ProtectedPolicies []string
+	Recorder          events.EventRecorder
+	// Limits caps the creation of AutomatedExceptions when set
+	Limits *CreationLimits
+	// DryRun records the operations instead of performing them when set
+	DryRun *DryRunRecorder
+	// Audit records every change to the AutomatedExceptions when set
+	Audit *audit.Trail
+	// Requeue decides when limited creations and deletions delayed by the grace period are retried,
+	// after DefaultRequeueDuration when unset
+	Requeue utils.RequeueStrategy
+	// ManifestBackoff spaces out the retries of Policies without PolicyManifest, they are retried after
+	// DefaultManifestBackoff when unset
+	ManifestBackoff *ManifestBackoff
+	// PolicyManifestChanges receives the PolicyManifests whose mode changed, when set
+	PolicyManifestChanges <-chan event.GenericEvent
+	// MaxConcurrentReconciles is the number of PolicyReports reconciled in parallel, 1 when unset
+	MaxConcurrentReconciles int
+	// Shard restricts the replica to the PolicyReports of its namespaces when set, the controller then runs on
+	// every replica instead of the leader only
+	Shard Shard
+	// ShardChanges receives the Namespaces newly owned by the replica, when set
+	ShardChanges <-chan event.GenericEvent
+}
+
+// Shard decides which namespaces are reconciled by the replica.
+type Shard interface {
+	Owns(namespace string) bool
 }
 
 //+kubebuilder:rbac:groups=kyverno.io.giantswarm.io,resources=policyreports,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kyverno.io.giantswarm.io,resources=policyreports/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kyverno.io.giantswarm.io,resources=policyreports/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=deployments;daemonsets;statefulsets;replicasets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 
 func (r *PolicyReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
-	_ = r.Log.WithValues("policyreport", req.NamespacedName)
+	ctx, span := tracing.Start(ctx, "PolicyReportReconciler.Reconcile", attribute.String("policyreport", req.String()))
+	result, err := r.reconcile(ctx, req)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (r *PolicyReportReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
 	reconcilerResourceType := "PolicyReport"
 
+	// The namespace is reconciled by another replica
+	if r.Shard != nil && !r.Shard.Owns(req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
+	// The PolicyReport may have been the last one referencing a Policy without PolicyManifest
+	defer r.pruneManifestBackoff(ctx)
+
 	var policyReport policyreport.PolicyReport
 
 	if err := r.Get(ctx, req.NamespacedName, &policyReport); err != nil {
-		if !errors.IsNotFound(err) {
-			// Error fetching the report
-			log.Log.Error(err, "unable to fetch PolicyReport")
-			// Add metric for failed PolicyReport reconciliation
-			ReconciliationFailuresMetric.WithLabelValues(reconcilerResourceType).Inc()
+		if errors.IsNotFound(err) {
+			// The PolicyReport or its workload was deleted
+			return ctrl.Result{}, r.deleteOrphan(ctx, req)
 		}
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		// Error fetching the report
+		logger.Error(err, "unable to fetch PolicyReport")
+		// Add metric for failed PolicyReport reconciliation
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
 	}
 
-	// Ignore report if namespace is excluded
-	if len(r.ExcludeNamespaces) != 0 {
-		for _, namespace := range r.ExcludeNamespaces {
-			if namespace == policyReport.Namespace {
-				// Namespace is excluded, skip
-				return reconcile.Result{}, nil
-			}
+	span := trace.SpanFromContext(ctx)
+	if policyReport.Scope != nil {
+		span.SetAttributes(
+			tracing.WorkloadNamespaceKey.String(policyReport.Scope.Namespace),
+			tracing.WorkloadKindKey.String(policyReport.Scope.Kind),
+			tracing.WorkloadNameKey.String(policyReport.Scope.Name),
+		)
+		logger = logger.WithValues("workloadNamespace", policyReport.Scope.Namespace, "workloadKind", policyReport.Scope.Kind, "workloadName", policyReport.Scope.Name)
+		ctx = log.IntoContext(ctx, logger)
+	}
+
+	// Protected Policies may only be allowed by the Namespace of the workload
+	var allowedProtectedPolicies []string
+	if len(r.ProtectedPolicies) != 0 && policyReport.Scope != nil {
+		var namespace corev1.Namespace
+		if err := r.Get(ctx, client.ObjectKey{Name: policyReport.Scope.Namespace}, &namespace); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "unable to fetch Namespace")
+			countFailure(reconcilerResourceType, FailureFetch, err)
+			return ctrl.Result{}, err
 		}
+		allowedProtectedPolicies = utils.AllowedProtectedPolicies(namespace.Annotations)
 	}
 
-	// Ignore report if kind is not part of TargetWorkloads
-	if !isKind(policyReport.Scope.Kind, r.TargetWorkloads) {
-		// Kind is not part of the targetWorkloads list, skip
+	recommendation := r.Recommend(ctx, policyReport, allowedProtectedPolicies)
+	span.SetAttributes(tracing.PoliciesKey.StringSlice(recommendation.FailedPolicies))
+	if recommendation.Skipped {
+		// Report is out of scope, skip
 		return reconcile.Result{}, nil
 	}
 
-	var failedPolicies []string
-	failure := false
-
-	for _, result := range policyReport.Results {
-		// Check the result status and PolicyCategory
-		if isPolicyCategory(result.Category, r.TargetCategories) {
-
-			// Failed result, create or update AutomatedException
-			if result.Result == "fail" {
-				// Check if Policy is in warming mode or not
-				log.Log.Info(fmt.Sprintf("Policy %s has failed for %s/%s", result.Policy, policyReport.Scope.Kind, policyReport.Scope.Name))
-
-				// Check Policy mode from cache
-				policyManifestMode := GetPolicyManifestMode(result.Policy, r.PolicyManifestCache)
-				switch policyManifestMode {
-				case ManifestExpectedMode:
-					// Add it to the list of failed policies if it isn't already
-					if !resultIsPresent(result.Policy, failedPolicies) {
-						failedPolicies = append(failedPolicies, result.Policy)
-					}
-				case "":
-					// Requeue when finished
-					failure = true
-				}
-			}
+	for _, policy := range recommendation.ProtectedPolicies {
+		logger.Info("Policy is protected, skipping", "policy", policy)
+		ProtectedPolicySkipsMetric.WithLabelValues(policyReport.Scope.Namespace, policy).Inc()
+		if r.Recorder != nil {
+			r.Recorder.Eventf(&policyReport, nil, corev1.EventTypeWarning, "ProtectedPolicy", "Draft",
+				"No AutomatedException is drafted for protected policy %s, a human-authored PolicyException is required", policy)
 		}
 	}
 
-	var namespace string
-
-	if r.DestinationNamespace == "" {
-		namespace = policyReport.Scope.Namespace
-	} else {
-		namespace = r.DestinationNamespace
+	failedPolicies := recommendation.FailedPolicies
+	failedPolicyCategories := recommendation.FailedPolicyCategories
+	failure := recommendation.ManifestMissing
+	if failure {
+		countFailure(reconcilerResourceType, FailureManifestMissing, nil)
 	}
+	namespace := recommendation.Namespace
+	automatedExceptionKey := client.ObjectKey{Name: string(policyReport.Scope.UID), Namespace: namespace}
+	logger = logger.WithValues("automatedException", automatedExceptionKey)
 
 	// Generate final Policy list
 	if len(failedPolicies) != 0 {
 
 		// Template AutomatedException
+		_, templateSpan := tracing.Start(ctx, "TemplateAutomatedException", tracing.PoliciesKey.StringSlice(failedPolicies))
 		automatedException := utils.TemplateAutomatedException(policyReport, failedPolicies, namespace)
+		templateSpan.End()
+
+		// Fetch the current AutomatedException
+		existing, err := getAutomatedException(ctx, r.Client, automatedException.Name, automatedException.Namespace)
+		if err != nil {
+			logger.Error(err, "unable to fetch AutomatedException")
+			countFailure(reconcilerResourceType, FailureFetch, err)
+			return ctrl.Result{}, err
+		}
+
+		if existing != nil {
+			// Approved AutomatedExceptions are owned by the reviewer, don't overwrite them
+			if utils.IsApproved(*existing) {
+				appliedAutomatedExceptions.Forget(automatedExceptionKey)
+				logger.V(DebugLevel).Info("AutomatedException has been approved, skipping", "approvedBy", existing.Annotations[utils.ApprovedByAnnotation])
+				automatedExceptionInventory.Set(automatedExceptionKey, policyReport.Scope.Namespace, policyReport.Scope.Kind, existing.Spec.Policies, failedPolicyCategories)
+				return ctrl.Result{}, nil
+			}
+			// Expired AutomatedExceptions are only renewed on request
+			if existing.Annotations[utils.ExpiredAnnotation] == "true" {
+				appliedAutomatedExceptions.Forget(automatedExceptionKey)
+				logger.V(DebugLevel).Info("AutomatedException has expired, skipping", "expiresAt", existing.Annotations[utils.ExpiresAtAnnotation])
+				automatedExceptionInventory.Set(automatedExceptionKey, policyReport.Scope.Namespace, policyReport.Scope.Kind, existing.Spec.Policies, failedPolicyCategories)
+				return ctrl.Result{}, nil
+			}
+		}
+
+		if existing != nil {
+			// Keep the annotations of the reviewers and the renewal requests
+			for _, annotation := range preservedAnnotations {
+				if value, ok := existing.Annotations[annotation]; ok {
+					if automatedException.Annotations == nil {
+						automatedException.Annotations = make(map[string]string)
+					}
+					automatedException.Annotations[annotation] = value
+				}
+			}
+		}
 
-		// Create or Update AutomatedException
+		// Stamp the expiry date, it is kept for the whole lifetime of the AutomatedException
+		if r.ExceptionTTL.Enabled() {
+			expiresAt := ""
+			if existing != nil && existing.Annotations[utils.ExpiresAtAnnotation] != "" {
+				expiresAt = existing.Annotations[utils.ExpiresAtAnnotation]
+			} else if ttl := r.ExceptionTTL.For(failedPolicyCategories); ttl > 0 {
+				expiresAt = time.Now().Add(ttl).UTC().Format(time.RFC3339)
+			}
+			if expiresAt != "" {
+				if automatedException.Annotations == nil {
+					automatedException.Annotations = make(map[string]string)
+				}
+				automatedException.Annotations[utils.ExpiresAtAnnotation] = expiresAt
+			}
+		}
+
+		// Limit the creation of new AutomatedExceptions
+		if existing == nil && r.DryRun == nil && r.Limits != nil {
+			limit, err := r.Limits.Allow(ctx, automatedExceptionKey, policyReport.Scope.Namespace, failedPolicies)
+			if err != nil {
+				logger.Error(err, "unable to check AutomatedException limits")
+				countFailure(reconcilerResourceType, FailureFetch, err)
+				return ctrl.Result{}, err
+			}
+			if limit != "" {
+				logger.Info("AutomatedException creation is limited, skipping", "limit", limit, "policies", failedPolicies)
+				LimitedCreationsMetric.WithLabelValues(limit).Inc()
+				if r.Recorder != nil {
+					r.Recorder.Eventf(&policyReport, nil, corev1.EventTypeWarning, "CreationLimited", "Draft",
+						"No AutomatedException is drafted for policies %v, the %s limit is reached", failedPolicies, limit)
+				}
+				// Retry once the limits may have been lifted
+				return r.requeue(), nil
+			}
+		}
+
+		// Create or Update AutomatedException, restoring it if it was deleted or modified by others
+		drift := appliedAutomatedExceptions.Drift(automatedExceptionKey, existing)
 		c := Controller{r.Client}
-		if op, err := c.CreateOrUpdate(ctx, &automatedException); err != nil {
+		if r.DryRun != nil {
+			// Only record the operation in dry-run mode
+			r.DryRun.Record(ctx, automatedExceptionKey, existing, &automatedException)
+		} else if op, err := c.CreateOrUpdate(ctx, &automatedException); err != nil {
 			// Error creating or updating AutomatedException
-			log.Log.Error(err, "unable to create or update AutomatedException")
+			if existing == nil && r.Limits != nil {
+				r.Limits.Release(automatedExceptionKey)
+			}
+			logger.Error(err, "unable to create or update AutomatedException", "policies", failedPolicies)
+			countFailure(reconcilerResourceType, FailureCreate, err)
 			return ctrl.Result{}, client.IgnoreNotFound(err)
 		} else {
+			span.SetAttributes(tracing.OperationKey.String(op))
+			appliedAutomatedExceptions.Set(automatedExceptionKey, &automatedException)
+			automatedExceptionInventory.Set(automatedExceptionKey, policyReport.Scope.Namespace, policyReport.Scope.Kind, failedPolicies, failedPolicyCategories)
+
 			switch op {
-			case "created":
-				log.Log.Info(fmt.Sprintf("Created AutomatedException %s/%s", automatedException.Namespace, automatedException.Name))
-			case "updated":
-				log.Log.Info(fmt.Sprintf("Updated AutomatedException %s/%s", automatedException.Namespace, automatedException.Name))
-			case "unchanged":
-				// This log is mainly for debugging, it should not be seen in stable release
-				log.Log.Info(fmt.Sprintf("AutomatedException %s/%s is up to date", automatedException.Namespace, automatedException.Name))
+			case CreateOp:
+				logger.Info("Created AutomatedException", "policies", failedPolicies)
+				reason := audit.ReasonFailing
+				if drift == DriftDeleted {
+					reason = audit.ReasonDrift
+					r.driftCorrected(ctx, policyReport, drift)
+				}
+				writeAudit(ctx, r.Audit, r.auditRecord(CreateOp, reason, policyReport, nil, &automatedException))
+				// Time from the earliest failed result to the AutomatedException creation
+				if !recommendation.FirstFailure.IsZero() {
+					TimeToExceptionMetric.Observe(time.Since(recommendation.FirstFailure).Seconds())
+				}
+				if r.Limits != nil {
+					if err := r.Limits.Created(ctx, time.Now()); err != nil {
+						logger.Error(err, "unable to open the circuit breaker")
+						countFailure(reconcilerResourceType, FailurePatch, err)
+					}
+				}
+			case UpdateOp:
+				logger.Info("Updated AutomatedException", "policies", failedPolicies)
+				reason := audit.ReasonFailing
+				if drift == DriftModified {
+					reason = audit.ReasonDrift
+					r.driftCorrected(ctx, policyReport, drift)
+				}
+				writeAudit(ctx, r.Audit, r.auditRecord(UpdateOp, reason, policyReport, existing, &automatedException))
+			case NoOp:
+				logger.V(DebugLevel).Info("AutomatedException is up to date", "policies", failedPolicies)
 			}
 		}
 	} else {
 		// Get current draft and delete it
-		// Delete AutomatedException
-		automatedException := policyAPI.AutomatedException{
-			ObjectMeta: ctrl.ObjectMeta{
-				Name:      policyReport.Scope.Name + "-" + strings.ToLower(policyReport.Scope.Kind),
-				Namespace: namespace,
-			},
-		}
-		if err := r.Delete(ctx, &automatedException, &client.DeleteOptions{}); err != nil {
-			// Error deleting the AutomatedException
-			if !errors.IsNotFound(err) {
-				log.Log.Error(err, "unable to delete AutomatedException")
+		existing, err := getAutomatedException(ctx, r.Client, automatedExceptionKey.Name, automatedExceptionKey.Namespace)
+		if err != nil {
+			logger.Error(err, "unable to fetch AutomatedException")
+			countFailure(reconcilerResourceType, FailureFetch, err)
+			return ctrl.Result{}, err
+		}
+		if existing == nil {
+			automatedExceptionInventory.Delete(automatedExceptionKey)
+		}
+		if r.DryRun != nil {
+			// Only record the operation in dry-run mode, the grace period can't be tracked without writes
+			if existing == nil || !utils.IsApproved(*existing) {
+				r.DryRun.Record(ctx, automatedExceptionKey, existing, nil)
+			}
+		} else if existing != nil && !utils.IsApproved(*existing) {
+			// Approved AutomatedExceptions are owned by the reviewer, don't delete them
+			// Wait for the results to stay clean during the grace period before deleting.
+			// The clean-* annotations are dropped by CreateOrUpdate as soon as the workload fails again.
+			before := existing.DeepCopy()
+			patch := client.MergeFrom(before)
+			if existing.Annotations == nil {
+				existing.Annotations = make(map[string]string)
+			}
+			if elapsed, remaining := r.DeletionGrace.Observe(existing.Annotations, time.Now()); !elapsed {
+				if err := r.Patch(ctx, existing, patch); err != nil {
+					logger.Error(err, "unable to patch AutomatedException")
+					countFailure(reconcilerResourceType, FailurePatch, err)
+					return ctrl.Result{}, client.IgnoreNotFound(err)
+				}
+				logger.Info("Delaying deletion of AutomatedException, results are clean", "cleanReconciles", existing.Annotations[utils.CleanReconcilesAnnotation], "cleanSince", existing.Annotations[utils.CleanSinceAnnotation])
+				SuppressedDeletionsMetric.WithLabelValues(existing.Namespace).Inc()
+				writeAudit(ctx, r.Audit, r.auditRecord(UpdateOp, audit.ReasonGracePeriod, policyReport, before, existing))
+
+				result := r.requeue()
+				if remaining > 0 && remaining < result.RequeueAfter {
+					result.RequeueAfter = remaining
+				}
+				return result, nil
+			}
+
+			// Delete AutomatedException
+			err := traced(ctx, "Delete", existing, func(ctx context.Context) error {
+				return r.Delete(ctx, existing, &client.DeleteOptions{})
+			})
+			if err != nil {
+				// Error deleting the AutomatedException
+				if !errors.IsNotFound(err) {
+					logger.Error(err, "unable to delete AutomatedException")
+					countFailure(reconcilerResourceType, FailureDelete, err)
+				}
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			} else {
+				logger.Info("Deleted AutomatedException because it doesn't have any failed results")
+				span.SetAttributes(tracing.OperationKey.String(DeleteOp))
+				OperationsMetric.WithLabelValues("AutomatedException", DeleteOp).Inc()
+				automatedExceptionInventory.Delete(automatedExceptionKey)
+				appliedAutomatedExceptions.Forget(automatedExceptionKey)
+				writeAudit(ctx, r.Audit, r.auditRecord(DeleteOp, audit.ReasonClean, policyReport, existing, nil))
 			}
-			return ctrl.Result{}, client.IgnoreNotFound(err)
-		} else {
-			log.Log.Info(fmt.Sprintf("Deleted AutomatedException %s/%s because it doesn't have any failed results", automatedException.Namespace, automatedException.Name))
 		}
 	}
 
 	if failure {
-		// Requeue due to failure without errors
-		return reconcile.Result{Requeue: true, RequeueAfter: 15 * time.Second}, nil
+		// Retry the Policies without PolicyManifest, the PolicyReport is enqueued right away once one is created
+		return r.retryMissingManifests(ctx, policyReport, recommendation.MissingManifests), nil
 	}
 
-	return utils.JitterRequeue(DefaultRequeueDuration, r.MaxJitterPercent, r.Log), nil
+	// Further changes are watched, only the cache resync period reconciles the PolicyReport again otherwise
+	return ctrl.Result{}, nil
+}
+
+// pruneManifestBackoff forgets the Policies without PolicyManifest which no PolicyReport references any more,
+// so that their retries and their MissingPolicyManifestsMetric series don't outlive their PolicyReports.
+func (r *PolicyReportReconciler) pruneManifestBackoff(ctx context.Context) {
+	if r.ManifestBackoff == nil {
+		return
+	}
+
+	for _, policy := range r.ManifestBackoff.Policies() {
+		var policyReports policyreport.PolicyReportList
+		if err := r.List(ctx, &policyReports, client.MatchingFields{PolicyReportPolicyIndex: policy}, client.Limit(1)); err != nil {
+			log.FromContext(ctx).Error(err, "unable to list PolicyReports", "policy", policy)
+			countFailure("PolicyReport", FailureFetch, err)
+			return
+		}
+		if len(policyReports.Items) == 0 {
+			r.ManifestBackoff.Forget(policy)
+		}
+	}
+}
+
+// deleteOrphan deletes the AutomatedException of a PolicyReport which no longer exists, once its workload is deleted
+// too. Kyverno names the PolicyReports after the UID of their workload, like the AutomatedExceptions.
+func (r *PolicyReportReconciler) deleteOrphan(ctx context.Context, req ctrl.Request) error {
+	logger := log.FromContext(ctx)
+
+	namespace := r.DestinationNamespace
+	if namespace == "" {
+		namespace = req.Namespace
+	}
+	automatedExceptionKey := client.ObjectKey{Name: req.Name, Namespace: namespace}
+	existing, err := getAutomatedException(ctx, r.Client, automatedExceptionKey.Name, automatedExceptionKey.Namespace)
+	if err != nil {
+		logger.Error(err, "unable to fetch AutomatedException")
+		countFailure("PolicyReport", FailureFetch, err)
+		return err
+	}
+	// Approved AutomatedExceptions are owned by the reviewer, don't delete them
+	if existing == nil || existing.Labels[utils.AppLabelName] != utils.ComponentName || utils.IsApproved(*existing) {
+		return nil
+	}
+
+	gvk, ok := WorkloadKinds[existing.Labels[utils.KindLabelName]]
+	if !ok {
+		return nil
+	}
+	workload := &metav1.PartialObjectMetadata{}
+	workload.SetGroupVersionKind(gvk)
+	err = r.Get(ctx, client.ObjectKey{Namespace: existing.Labels[utils.NamespaceLabelName], Name: existing.Labels[utils.NameLabelName]}, workload)
+	switch {
+	case err == nil && string(workload.UID) == existing.Name:
+		// The PolicyReport will be recreated for the workload
+		return nil
+	case err != nil && !errors.IsNotFound(err):
+		logger.Error(err, "unable to fetch workload")
+		countFailure("PolicyReport", FailureFetch, err)
+		return err
+	}
+
+	logger = logger.WithValues("automatedException", automatedExceptionKey)
+	if r.DryRun != nil {
+		r.DryRun.Record(ctx, automatedExceptionKey, existing, nil)
+		return nil
+	}
+	err = traced(ctx, "Delete", existing, func(ctx context.Context) error {
+		return r.Delete(ctx, existing)
+	})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "unable to delete AutomatedException")
+			countFailure("PolicyReport", FailureDelete, err)
+		}
+		return client.IgnoreNotFound(err)
+	}
+	logger.Info("Deleted AutomatedException because its workload was deleted")
+	OperationsMetric.WithLabelValues("AutomatedException", DeleteOp).Inc()
+	automatedExceptionInventory.Delete(automatedExceptionKey)
+	appliedAutomatedExceptions.Forget(automatedExceptionKey)
+	writeAudit(ctx, r.Audit, automatedExceptionRecord("policyreport", DeleteOp, audit.ReasonOrphaned, existing, nil))
+
+	return nil
+}
+
+// requeue retries the PolicyReport according to the Requeue strategy.
+func (r *PolicyReportReconciler) requeue() ctrl.Result {
+	if r.Requeue == nil {
+		return ctrl.Result{RequeueAfter: DefaultRequeueDuration}
+	}
+	return utils.Requeue(r.Requeue, 0)
+}
+
+// retryMissingManifests reports the Policies without PolicyManifest and returns when to retry the earliest of them.
+func (r *PolicyReportReconciler) retryMissingManifests(ctx context.Context, policyReport policyreport.PolicyReport, policies []string) ctrl.Result {
+	logger := log.FromContext(ctx)
+	now := time.Now()
+
+	var retryAfter time.Duration
+	for _, policy := range policies {
+		delay := DefaultManifestBackoff
+		if r.ManifestBackoff != nil {
+			delay = r.ManifestBackoff.Missing(policy, now)
+		}
+		logger.Info("PolicyManifest not found, retrying later", "policy", policy, "retryAfter", delay)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(&policyReport, nil, corev1.EventTypeWarning, "ManifestMissing", "Draft",
+				"No PolicyManifest found for policy %s, retrying in %s", policy, delay)
+		}
+		if retryAfter == 0 || delay < retryAfter {
+			retryAfter = delay
+		}
+	}
+
+	// Spread the retries of the PolicyReports, without retrying any of them before the delay
+	jitterPercent := 0
+	if r.ManifestBackoff != nil {
+		jitterPercent = r.ManifestBackoff.Strategy.JitterPercent
+	}
+	jittered, err := utils.Jitter(retryAfter, jitterPercent)
+	if err != nil {
+		logger.Error(err, "Failed to calculate jitter")
+		jittered = retryAfter
+	}
+	if jittered < retryAfter {
+		jittered = 2*retryAfter - jittered
+	}
+
+	return ctrl.Result{RequeueAfter: jittered}
+}
+
+// driftCorrected reports an AutomatedException restored after being deleted or modified by others.
+func (r *PolicyReportReconciler) driftCorrected(ctx context.Context, policyReport policyreport.PolicyReport, drift string) {
+	log.FromContext(ctx).Info("Restored AutomatedException changed by others", "drift", drift)
+	DriftCorrectionsMetric.WithLabelValues(policyReport.Scope.Namespace, drift).Inc()
+	if r.Recorder != nil {
+		r.Recorder.Eventf(&policyReport, nil, corev1.EventTypeWarning, "DriftCorrected", "Restore",
+			"AutomatedException was %s by others and has been restored", drift)
+	}
+}
+
+// Recommendation is the outcome of filtering a PolicyReport.
+type Recommendation struct {
+	// Skipped is true if the PolicyReport is out of scope
+	Skipped bool
+	// FailedPolicies lists the failed Policies in warming mode
+	FailedPolicies []string
+	// FailedPolicyCategories maps the failed Policies to their category
+	FailedPolicyCategories map[string]string
+	// ProtectedPolicies lists the failed protected Policies which were not allowed by the Namespace
+	ProtectedPolicies []string
+	// ManifestMissing is true if a failed Policy has no PolicyManifest yet
+	ManifestMissing bool
+	// MissingManifests lists the failed Policies without PolicyManifest
+	MissingManifests []string
+	// Namespace is where the AutomatedException belongs
+	Namespace string
+	// FirstFailure is the timestamp of the earliest failed result in warming mode, if known
+	FirstFailure time.Time
+	// Decisions traces every decision taken while filtering the PolicyReport
+	Decisions []Decision
+}
+
+// Recommend filters the PolicyReport results and returns the Policies which must be excepted for its workload.
+// Protected Policies are only excepted if listed in allowedProtectedPolicies, as read from the Namespace.
+// It doesn't access the API server and can be used offline.
+func (r *PolicyReportReconciler) Recommend(ctx context.Context, policyReport policyreport.PolicyReport, allowedProtectedPolicies []string) Recommendation {
+	recommendation := Recommendation{
+		FailedPolicyCategories: make(map[string]string),
+	}
+
+	// Ignore reports without a workload
+	if policyReport.Scope == nil {
+		recommendation.decide(StepScope, "", OutcomeSkipped, "PolicyReport has no workload scope")
+		recommendation.Skipped = true
+		return recommendation
+	}
+
+	// Ignore report if namespace is excluded
+	for _, namespace := range r.ExcludeNamespaces {
+		if namespace == policyReport.Namespace {
+			// Namespace is excluded, skip
+			recommendation.decide(StepNamespace, "", OutcomeSkipped, fmt.Sprintf("namespace %s is excluded", namespace))
+			recommendation.Skipped = true
+			return recommendation
+		}
+	}
+	recommendation.decide(StepNamespace, "", OutcomeAccepted, fmt.Sprintf("namespace %s is not excluded", policyReport.Namespace))
+
+	// Ignore report if kind is not part of TargetWorkloads
+	if !isKind(policyReport.Scope.Kind, r.TargetWorkloads) {
+		// Kind is not part of the targetWorkloads list, skip
+		recommendation.decide(StepKind, "", OutcomeSkipped, fmt.Sprintf("kind %s is not a target workload %v", policyReport.Scope.Kind, r.TargetWorkloads))
+		recommendation.Skipped = true
+		return recommendation
+	}
+	recommendation.decide(StepKind, "", OutcomeAccepted, fmt.Sprintf("kind %s is a target workload", policyReport.Scope.Kind))
+
+	for _, result := range policyReport.Results {
+		// Check the result status and PolicyCategory
+		if !isPolicyCategory(result.Category, r.TargetCategories) {
+			recommendation.decide(StepCategory, result.Policy, OutcomeSkipped, fmt.Sprintf("category %q is not a target category", result.Category))
+			continue
+		}
+
+		// Failed result, create or update AutomatedException
+		if result.Result != "fail" {
+			recommendation.decide(StepResult, result.Policy, OutcomeSkipped, fmt.Sprintf("result is %s", result.Result))
+			continue
+		}
+
+		// Check if Policy is in warming mode or not
+		log.FromContext(ctx).V(DebugLevel).Info("Policy has failed", "policy", result.Policy)
+
+		// Protected Policies are never excepted automatically, whatever their mode
+		if resultIsPresent(result.Policy, r.ProtectedPolicies) {
+			if !resultIsPresent(result.Policy, allowedProtectedPolicies) {
+				if !resultIsPresent(result.Policy, recommendation.ProtectedPolicies) {
+					recommendation.ProtectedPolicies = append(recommendation.ProtectedPolicies, result.Policy)
+				}
+				recommendation.decide(StepProtected, result.Policy, OutcomeSkipped, "Policy is protected and requires a human-authored exception")
+				continue
+			}
+			recommendation.decide(StepProtected, result.Policy, OutcomeAccepted, fmt.Sprintf("Policy is protected but allowed by the %s annotation of the namespace", utils.AllowProtectedPoliciesAnnotation))
+		}
+
+		// Check Policy mode from cache
+		_, manifestSpan := tracing.Start(ctx, "GetPolicyManifestMode", tracing.PolicyKey.String(result.Policy))
+		policyManifestMode := GetPolicyManifestMode(result.Policy, r.PolicyManifestCache)
+		manifestSpan.SetAttributes(tracing.PolicyManifestModeKey.String(policyManifestMode))
+		manifestSpan.End()
+		switch policyManifestMode {
+		case ManifestExpectedMode:
+			// Add it to the list of failed policies if it isn't already
+			if !resultIsPresent(result.Policy, recommendation.FailedPolicies) {
+				recommendation.FailedPolicies = append(recommendation.FailedPolicies, result.Policy)
+				recommendation.FailedPolicyCategories[result.Policy] = result.Category
+			}
+			if result.Timestamp.Seconds > 0 {
+				timestamp := time.Unix(result.Timestamp.Seconds, int64(result.Timestamp.Nanos))
+				if recommendation.FirstFailure.IsZero() || timestamp.Before(recommendation.FirstFailure) {
+					recommendation.FirstFailure = timestamp
+				}
+			}
+			recommendation.decide(StepManifest, result.Policy, OutcomeAccepted, fmt.Sprintf("PolicyManifest is in %s mode", policyManifestMode))
+		case "":
+			// Requeue when finished
+			recommendation.ManifestMissing = true
+			if !resultIsPresent(result.Policy, recommendation.MissingManifests) {
+				recommendation.MissingManifests = append(recommendation.MissingManifests, result.Policy)
+			}
+			recommendation.decide(StepManifest, result.Policy, OutcomeSkipped, "PolicyManifest not found, retrying later")
+		default:
+			recommendation.decide(StepManifest, result.Policy, OutcomeSkipped, fmt.Sprintf("PolicyManifest is in %s mode", policyManifestMode))
+		}
+	}
+
+	if r.DestinationNamespace == "" {
+		recommendation.Namespace = policyReport.Scope.Namespace
+	} else {
+		recommendation.Namespace = r.DestinationNamespace
+	}
+
+	return recommendation
 }
 
 func resultIsPresent(result string, failedResults []string) bool {
@@ -209,10 +697,50 @@ func isPolicyCategory(resultCategory string, targetCategories []string) bool {
 	return false
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. PolicyReports are reconciled on changes to their results,
+// to the PolicyManifests of their Policies, to their AutomatedException, to the allowed protected Policies of their
+// Namespace and on the deletion of their workload.
 func (r *PolicyReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		// Uncomment the following line adding a pointer to an instance of the controlled resource as an argument
-		For(&policyreport.PolicyReport{}).
+	ctx := context.Background()
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &policyreport.PolicyReport{}, PolicyReportPolicyIndex, indexPolicyReportPolicies); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &policyreport.PolicyReport{}, PolicyReportScopeUIDIndex, indexPolicyReportScopeUID); err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&policyreport.PolicyReport{}, builder.WithPredicates(r.PolicyReportPredicates())).
+		Watches(&policyAPI.AutomatedException{}, handler.EnqueueRequestsFromMapFunc(r.automatedExceptionRequests),
+			builder.WithPredicates(managedPredicate, predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{})))
+
+	if r.PolicyManifestChanges != nil {
+		b = b.WatchesRawSource(source.Channel(r.PolicyManifestChanges, handler.EnqueueRequestsFromMapFunc(r.policyManifestRequests)))
+	}
+	if r.ShardChanges != nil {
+		b = b.WatchesRawSource(source.Channel(r.ShardChanges, handler.EnqueueRequestsFromMapFunc(r.namespaceRequests)))
+	}
+	if len(r.ProtectedPolicies) != 0 {
+		b = b.Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.namespaceRequests),
+			builder.WithPredicates(allowedProtectedPoliciesChanged))
+	}
+	// Only the metadata of the workloads is cached
+	for _, kind := range r.TargetWorkloads {
+		gvk, ok := WorkloadKinds[kind]
+		if !ok {
+			continue
+		}
+		workload := &metav1.PartialObjectMetadata{}
+		workload.SetGroupVersionKind(gvk)
+		b = b.WatchesMetadata(workload, handler.EnqueueRequestsFromMapFunc(workloadRequests), builder.WithPredicates(deletedPredicate))
+	}
+
+	options := crcontroller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}
+	if r.Shard != nil {
+		options.NeedLeaderElection = ptr.To(false)
+	}
+	return b.
+		WithOptions(options).
+		WithLogConstructor(logConstructor(r.Log, mgr, "policyreport", "policyreport")).
 		Complete(r)
 }
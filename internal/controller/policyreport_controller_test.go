@@ -25,6 +25,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -128,6 +129,21 @@ var _ = Describe("PolicyReport controller", func() {
 				}, timeout, interval).Should(BeTrue())
 			})
 		})
+
+		When("the PolicyReport no longer has failed results", func() {
+			It("must delete the Giant Swarm AutomatedException", func() {
+				policyReport := &wgpolicyk8s.PolicyReport{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: PolicyReportName, Namespace: PolicyReportNamespace}, policyReport)).Should(Succeed())
+
+				policyReport.Results[0].Result = "pass"
+				Expect(k8sClient.Update(ctx, policyReport)).Should(Succeed())
+
+				Eventually(func() bool {
+					err := k8sClient.Get(ctx, automatedExceptionLookupKey, &automatedException)
+					return errors.IsNotFound(err)
+				}, timeout, interval).Should(BeTrue())
+			})
+		})
 	})
 
 })
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// PolicyReportPredicates filters the PolicyReport events before they are queued. PolicyReports without workload,
+// of a kind which isn't targeted or in an excluded namespace are ignored, as well as updates which leave the
+// results of the target categories unchanged, e.g. only bumping the summary or the timestamps. Resyncs of the
+// cache, which deliver the unchanged PolicyReport, are always reconciled as a safety net.
+func (r *PolicyReportReconciler) PolicyReportPredicates() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return r.inScope(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !r.inScope(e.ObjectNew) {
+				return false
+			}
+			if e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion() {
+				return true
+			}
+			return !r.inScope(e.ObjectOld) || r.ResultsHash(e.ObjectOld) != r.ResultsHash(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return r.inScope(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return r.inScope(e.Object)
+		},
+	}
+}
+
+// inScope mirrors the scope checks of Recommend.
+func (r *PolicyReportReconciler) inScope(obj client.Object) bool {
+	policyReport, ok := obj.(*policyreport.PolicyReport)
+	if !ok || policyReport.Scope == nil {
+		return false
+	}
+	for _, namespace := range r.ExcludeNamespaces {
+		if namespace == policyReport.Namespace {
+			return false
+		}
+	}
+	return isKind(policyReport.Scope.Kind, r.TargetWorkloads)
+}
+
+// ResultsHash hashes the sorted policy, rule and result tuples of the PolicyReport results in the target categories.
+func (r *PolicyReportReconciler) ResultsHash(obj client.Object) string {
+	policyReport, ok := obj.(*policyreport.PolicyReport)
+	if !ok {
+		return ""
+	}
+
+	tuples := make([]string, 0, len(policyReport.Results))
+	for _, result := range policyReport.Results {
+		if !isPolicyCategory(result.Category, r.TargetCategories) {
+			continue
+		}
+		tuples = append(tuples, result.Policy+"\x00"+result.Rule+"\x00"+string(result.Result))
+	}
+	sort.Strings(tuples)
+
+	hash := sha256.New()
+	if policyReport.Scope != nil {
+		hash.Write([]byte(policyReport.Scope.UID + "\n"))
+	}
+	for _, tuple := range tuples {
+		hash.Write([]byte(tuple + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+const predicateCategory = "Pod Security Standards (Restricted)"
+
+func predicateReconciler() *PolicyReportReconciler {
+	return &PolicyReportReconciler{
+		TargetWorkloads:     []string{"Deployment"},
+		TargetCategories:    []string{predicateCategory},
+		ExcludeNamespaces:   []string{"kube-system"},
+		Requeue:             utils.FixedRequeue{Interval: DefaultRequeueDuration, JitterPercent: 10},
+		PolicyManifestCache: expectedManifests("require-run-as-nonroot"),
+	}
+}
+
+// expectedManifests caches PolicyManifests of the Policies in the expected mode.
+func expectedManifests(policies ...string) *PolicyManifestCache {
+	cache := NewPolicyManifestCache()
+	for _, policy := range policies {
+		cache.Set(policyAPI.PolicyManifest{ObjectMeta: metav1.ObjectMeta{Name: policy}, Spec: policyAPI.PolicyManifestSpec{Mode: ManifestExpectedMode}})
+	}
+	return cache
+}
+
+func predicatePolicyReport(namespace string, name string, result policyreport.PolicyResult) *policyreport.PolicyReport {
+	return &policyreport.PolicyReport{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Scope:      &corev1.ObjectReference{Kind: "Deployment", Name: name, Namespace: namespace, UID: types.UID(namespace + "-" + name)},
+		Summary:    policyreport.PolicyReportSummary{Fail: 1},
+		Results: []policyreport.PolicyReportResult{
+			{Policy: "require-run-as-nonroot", Rule: "run-as-nonroot", Category: predicateCategory, Result: result},
+			{Policy: "require-labels", Rule: "check-labels", Category: "Best Practices", Result: "pass"},
+		},
+	}
+}
+
+var _ = Describe("PolicyReport predicates", func() {
+	predicates := predicateReconciler().PolicyReportPredicates()
+
+	It("ignores PolicyReports out of scope", func() {
+		Expect(predicates.Create(event.CreateEvent{Object: predicatePolicyReport("team-a", "api", "fail")})).To(BeTrue())
+		Expect(predicates.Create(event.CreateEvent{Object: predicatePolicyReport("kube-system", "coredns", "fail")})).To(BeFalse())
+
+		daemonSet := predicatePolicyReport("team-a", "agent", "fail")
+		daemonSet.Scope.Kind = "DaemonSet"
+		Expect(predicates.Create(event.CreateEvent{Object: daemonSet})).To(BeFalse())
+
+		unscoped := predicatePolicyReport("team-a", "api", "fail")
+		unscoped.Scope = nil
+		Expect(predicates.Create(event.CreateEvent{Object: unscoped})).To(BeFalse())
+		Expect(predicates.Delete(event.DeleteEvent{Object: unscoped})).To(BeFalse())
+	})
+
+	It("skips updates leaving the target results unchanged", func() {
+		old := predicatePolicyReport("team-a", "api", "fail")
+		old.ResourceVersion = "1"
+
+		bumped := old.DeepCopy()
+		bumped.ResourceVersion = "2"
+		bumped.Summary.Pass = 3
+		bumped.Results[0].Timestamp = metav1.Timestamp{Seconds: 1700000000}
+		bumped.Results[0].Message = "validation rule 'run-as-nonroot' failed"
+		Expect(predicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: bumped})).To(BeFalse())
+
+		// Results are compared whatever their order
+		reordered := old.DeepCopy()
+		reordered.ResourceVersion = "2"
+		reordered.Results[0], reordered.Results[1] = reordered.Results[1], reordered.Results[0]
+		Expect(predicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: reordered})).To(BeFalse())
+
+		// Results of other categories are ignored
+		otherCategory := old.DeepCopy()
+		otherCategory.ResourceVersion = "2"
+		otherCategory.Results[1].Result = "fail"
+		Expect(predicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: otherCategory})).To(BeFalse())
+	})
+
+	It("reconciles the resyncs of the cache", func() {
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		policyReport.ResourceVersion = "1"
+		Expect(predicates.Update(event.UpdateEvent{ObjectOld: policyReport, ObjectNew: policyReport.DeepCopy()})).To(BeTrue())
+
+		// Unless out of scope
+		excluded := predicatePolicyReport("kube-system", "coredns", "fail")
+		Expect(predicates.Update(event.UpdateEvent{ObjectOld: excluded, ObjectNew: excluded.DeepCopy()})).To(BeFalse())
+	})
+
+	It("delivers the resyncs of the cache to the reconciler", func() {
+		ctx := context.Background()
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		reconciler := predicateReconciler()
+		reconciler.Client = watchClient(policyReport)
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(policyReport), policyReport)).To(Succeed())
+		DeferCleanup(appliedAutomatedExceptions.Forget, types.NamespacedName{Namespace: "team-a", Name: "team-a-api"})
+
+		informers := &informertest.FakeInformers{Scheme: reconciler.Client.Scheme()}
+		queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		DeferCleanup(queue.ShutDown)
+		policyReports := source.Kind[client.Object](informers, &policyreport.PolicyReport{}, &handler.EnqueueRequestForObject{}, reconciler.PolicyReportPredicates())
+		Expect(policyReports.Start(ctx, queue)).To(Succeed())
+		Expect(policyReports.WaitForSync(ctx)).To(Succeed())
+
+		informer, err := informers.FakeInformerFor(ctx, &policyreport.PolicyReport{})
+		Expect(err).NotTo(HaveOccurred())
+		informer.Update(policyReport, policyReport.DeepCopy())
+
+		Eventually(queue.Len).Should(Equal(1))
+		request, _ := queue.Get()
+		_, err = reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "team-a-api"}, &policyAPI.AutomatedException{})).To(Succeed())
+	})
+
+	It("reconciles updates changing the target results", func() {
+		old := predicatePolicyReport("team-a", "api", "fail")
+
+		passed := old.DeepCopy()
+		passed.Results[0].Result = "pass"
+		Expect(predicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: passed})).To(BeTrue())
+
+		added := old.DeepCopy()
+		added.Results = append(added.Results, policyreport.PolicyReportResult{Policy: "disallow-capabilities", Rule: "adding-capabilities", Category: predicateCategory, Result: "fail"})
+		Expect(predicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: added})).To(BeTrue())
+	})
+})
+
+// BenchmarkPolicyReportEvents replays PolicyReport updates, of which only one in ten changes the results,
+// and reports the API calls performed by the reconciliations per event.
+func BenchmarkPolicyReportEvents(b *testing.B) {
+	for _, filtered := range []bool{false, true} {
+		b.Run(fmt.Sprintf("predicates=%t", filtered), func(b *testing.B) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				b.Fatal(err)
+			}
+			if err := policyAPI.AddToScheme(scheme); err != nil {
+				b.Fatal(err)
+			}
+			if err := policyreport.AddToScheme(scheme); err != nil {
+				b.Fatal(err)
+			}
+
+			var calls atomic.Int64
+			count := func() { calls.Add(1) }
+			policyReport := predicatePolicyReport("team-a", "api", "fail")
+			reconciler := predicateReconciler()
+			reconciler.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(policyReport).WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					count()
+					return c.Get(ctx, key, obj, opts...)
+				},
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					count()
+					return c.Create(ctx, obj, opts...)
+				},
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					count()
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					count()
+					return c.Delete(ctx, obj, opts...)
+				},
+			}).Build()
+			predicates := reconciler.PolicyReportPredicates()
+			request := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				old := policyReport.DeepCopy()
+				policyReport.Summary.Pass++
+				if i%10 == 0 {
+					if policyReport.Results[0].Result == "fail" {
+						policyReport.Results[0].Result = "pass"
+					} else {
+						policyReport.Results[0].Result = "fail"
+					}
+				}
+				if err := reconciler.Update(context.Background(), policyReport); err != nil {
+					b.Fatal(err)
+				}
+				if filtered && !predicates.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: policyReport}) {
+					continue
+				}
+				if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(calls.Load())/float64(b.N), "api-calls/op")
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("Protected policies", func() {
+	const (
+		Namespace = "protected"
+		Category  = "Pod Security Standards (Baseline)"
+	)
+
+	policyReport := func() *policyreport.PolicyReport {
+		return &policyreport.PolicyReport{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: Namespace},
+			Scope:      &corev1.ObjectReference{Kind: "Deployment", Name: "app", Namespace: Namespace, UID: "protected-app"},
+			Results: []policyreport.PolicyReportResult{
+				{Policy: "disallow-privileged-containers", Category: Category, Result: "fail"},
+				{Policy: "disallow-capabilities", Category: Category, Result: "fail"},
+			},
+		}
+	}
+	newReconciler := func() *PolicyReportReconciler {
+		return &PolicyReportReconciler{
+			TargetWorkloads:     []string{"Deployment"},
+			TargetCategories:    []string{Category},
+			ProtectedPolicies:   []string{"disallow-privileged-containers"},
+			Requeue:             utils.FixedRequeue{Interval: DefaultRequeueDuration, JitterPercent: 10},
+			PolicyManifestCache: expectedManifests("disallow-privileged-containers", "disallow-capabilities"),
+		}
+	}
+
+	It("never recommends protected policies", func() {
+		recommendation := newReconciler().Recommend(context.Background(), *policyReport(), nil)
+
+		Expect(recommendation.FailedPolicies).To(Equal([]string{"disallow-capabilities"}))
+		Expect(recommendation.ProtectedPolicies).To(Equal([]string{"disallow-privileged-containers"}))
+		Expect(recommendation.Decisions).To(ContainElement(Decision{
+			Step:    StepProtected,
+			Policy:  "disallow-privileged-containers",
+			Outcome: OutcomeSkipped,
+			Reason:  "Policy is protected and requires a human-authored exception",
+		}))
+	})
+
+	It("recommends protected policies allowed by the namespace", func() {
+		allowed := utils.AllowedProtectedPolicies(map[string]string{utils.AllowProtectedPoliciesAnnotation: "disallow-privileged-containers, disallow-host-path"})
+		recommendation := newReconciler().Recommend(context.Background(), *policyReport(), allowed)
+
+		Expect(recommendation.FailedPolicies).To(ConsistOf("disallow-capabilities", "disallow-privileged-containers"))
+		Expect(recommendation.ProtectedPolicies).To(BeEmpty())
+	})
+
+	Describe("reconciling a PolicyReport", func() {
+		var reconciler *PolicyReportReconciler
+		var recorder *events.FakeRecorder
+		var namespace *corev1.Namespace
+
+		BeforeEach(func() {
+			recorder = events.NewFakeRecorder(10)
+			namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: Namespace}}
+			reconciler = newReconciler()
+			reconciler.Recorder = recorder
+		})
+
+		reconcile := func() *policyAPI.AutomatedException {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(policyAPI.AddToScheme(scheme)).To(Succeed())
+			Expect(policyreport.AddToScheme(scheme)).To(Succeed())
+			reconciler.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace, policyReport()).Build()
+
+			_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "app", Namespace: Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			var automatedException policyAPI.AutomatedException
+			err = reconciler.Get(context.Background(), client.ObjectKey{Name: "protected-app", Namespace: Namespace}, &automatedException)
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			Expect(err).NotTo(HaveOccurred())
+			return &automatedException
+		}
+
+		It("emits an Event and counts the skipped protected policy", func() {
+			before := testutil.ToFloat64(ProtectedPolicySkipsMetric.WithLabelValues(Namespace, "disallow-privileged-containers"))
+
+			automatedException := reconcile()
+
+			Expect(automatedException.Spec.Policies).To(Equal([]string{"disallow-capabilities"}))
+			Expect(recorder.Events).To(Receive(ContainSubstring("ProtectedPolicy")))
+			Expect(testutil.ToFloat64(ProtectedPolicySkipsMetric.WithLabelValues(Namespace, "disallow-privileged-containers"))).To(Equal(before + 1))
+		})
+
+		It("drafts the protected policy with the namespace override", func() {
+			namespace.Annotations = map[string]string{utils.AllowProtectedPoliciesAnnotation: "disallow-privileged-containers"}
+
+			automatedException := reconcile()
+
+			Expect(automatedException.Spec.Policies).To(ConsistOf("disallow-capabilities", "disallow-privileged-containers"))
+			Expect(recorder.Events).NotTo(Receive())
+		})
+	})
+})
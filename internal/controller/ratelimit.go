@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+// writeLimitedClient waits for a token of the limiter before writing AutomatedExceptions.
+// Other resources and reads are not limited.
+type writeLimitedClient struct {
+	client.Client
+	limiter flowcontrol.RateLimiter
+}
+
+// NewWriteLimitedClient returns a client throttling the writes of AutomatedExceptions to qps per second, with bursts
+// of up to burst writes. The returned client shares the token bucket between all the reconcilers using it.
+func NewWriteLimitedClient(c client.Client, qps float32, burst int) client.Client {
+	return &writeLimitedClient{
+		Client:  c,
+		limiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+	}
+}
+
+// wait blocks until the AutomatedException may be written, or the context is done.
+func (c *writeLimitedClient) wait(ctx context.Context, obj client.Object) error {
+	if _, ok := obj.(*policyAPI.AutomatedException); !ok {
+		return nil
+	}
+
+	start := time.Now()
+	err := c.limiter.Wait(ctx)
+	WriteThrottleMetric.Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+func (c *writeLimitedClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.wait(ctx, obj); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *writeLimitedClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.wait(ctx, obj); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *writeLimitedClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.wait(ctx, obj); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *writeLimitedClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.wait(ctx, obj); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+var _ = Describe("Write limiter", func() {
+	ctx := context.Background()
+
+	It("throttles the writes of AutomatedExceptions beyond the burst", func() {
+		c := NewWriteLimitedClient(watchClient(), 50, 5)
+
+		start := time.Now()
+		for i := 0; i < 20; i++ {
+			Expect(c.Create(ctx, &policyAPI.AutomatedException{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("app-%d", i), Namespace: "team-a"}})).To(Succeed())
+		}
+		// 15 writes beyond the burst at 50 writes per second
+		Expect(time.Since(start)).To(BeNumerically(">=", 280*time.Millisecond))
+	})
+
+	It("doesn't throttle other resources", func() {
+		c := NewWriteLimitedClient(watchClient(), 1, 1)
+
+		start := time.Now()
+		for i := 0; i < 20; i++ {
+			Expect(c.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("app-%d", i), Namespace: "team-a"}})).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	It("gives up once the context is done", func() {
+		c := NewWriteLimitedClient(watchClient(), 0.1, 1)
+		automatedException := func(name string) client.Object {
+			return &policyAPI.AutomatedException{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"}}
+		}
+		Expect(c.Create(ctx, automatedException("api"))).To(Succeed())
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		Expect(c.Create(timeoutCtx, automatedException("web"))).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,286 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// Reasons of the ReadyToEnforce condition
+const (
+	ReasonNoFailures        = "NoFailures"
+	ReasonFailuresCovered   = "FailuresCovered"
+	ReasonUncoveredFailures = "UncoveredFailures"
+)
+
+// maxUncoveredWorkloads bounds the size of the EnforcementReadiness status
+const maxUncoveredWorkloads = 20
+
+// EnforcementReadinessReconciler maintains an EnforcementReadiness per PolicyManifest, telling whether every
+// workload failing the Policy is covered by an AutomatedException. The PolicyReports are listed with the
+// PolicyReportPolicyIndex of the PolicyReportReconciler.
+type EnforcementReadinessReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+}
+
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=enforcementreadinesses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=enforcementreadinesses/status,verbs=get;update;patch
+
+func (r *EnforcementReadinessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	reconcilerResourceType := "EnforcementReadiness"
+
+	var policyManifest policyAPI.PolicyManifest
+	if err := r.Get(ctx, req.NamespacedName, &policyManifest); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "unable to fetch PolicyManifest")
+			countFailure(reconcilerResourceType, FailureFetch, err)
+			return ctrl.Result{}, err
+		}
+		// The EnforcementReadiness is garbage collected along with its PolicyManifest
+		deleteReadinessMetrics(req.Name)
+		return ctrl.Result{}, nil
+	}
+	if !policyManifest.DeletionTimestamp.IsZero() {
+		deleteReadinessMetrics(req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	var policyReports policyreport.PolicyReportList
+	if err := r.List(ctx, &policyReports, client.MatchingFields{PolicyReportPolicyIndex: policyManifest.Name}); err != nil {
+		logger.Error(err, "unable to list PolicyReports")
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
+	}
+
+	var automatedExceptions policyAPI.AutomatedExceptionList
+	if err := r.List(ctx, &automatedExceptions, client.MatchingLabels{utils.AppLabelName: utils.ComponentName}); err != nil {
+		logger.Error(err, "unable to list AutomatedExceptions")
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
+	}
+
+	var readiness recommenderAPI.EnforcementReadiness
+	err := r.Get(ctx, types.NamespacedName{Name: policyManifest.Name}, &readiness)
+	switch {
+	case errors.IsNotFound(err):
+		readiness = recommenderAPI.EnforcementReadiness{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   policyManifest.Name,
+				Labels: map[string]string{utils.AppLabelName: utils.ComponentName},
+			},
+		}
+		// Remove the EnforcementReadiness with its PolicyManifest
+		if err := controllerutil.SetOwnerReference(&policyManifest, &readiness, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(ctx, &readiness); err != nil {
+			logger.Error(err, "unable to create EnforcementReadiness")
+			countFailure(reconcilerResourceType, FailureCreate, err)
+			return ctrl.Result{}, err
+		}
+		OperationsMetric.WithLabelValues(reconcilerResourceType, CreateOp).Inc()
+	case err != nil:
+		logger.Error(err, "unable to fetch EnforcementReadiness")
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
+	}
+
+	status := AssessEnforcementReadiness(policyManifest, policyReports.Items, automatedExceptions.Items, readiness.Status.Conditions)
+	setReadinessMetrics(policyManifest.Name, status)
+
+	if equality.Semantic.DeepEqual(readiness.Status, status) {
+		logger.V(DebugLevel).Info("EnforcementReadiness is up to date")
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFrom(readiness.DeepCopy())
+	readiness.Status = status
+	if err := r.Status().Patch(ctx, &readiness, patch); err != nil {
+		logger.Error(err, "unable to update EnforcementReadiness status")
+		countFailure(reconcilerResourceType, FailurePatch, err)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	OperationsMetric.WithLabelValues(reconcilerResourceType, UpdateOp).Inc()
+	logger.Info("Updated EnforcementReadiness", "outstandingFailures", status.OutstandingFailures, "coveredFailures", status.CoveredFailures)
+
+	return ctrl.Result{}, nil
+}
+
+// AssessEnforcementReadiness counts the workloads failing the Policy of the PolicyManifest and those covered by
+// an AutomatedException for it. Expired AutomatedExceptions and those not managed by the recommender don't cover any
+// failure. The existing conditions keep their transition time.
+func AssessEnforcementReadiness(policyManifest policyAPI.PolicyManifest, policyReports []policyreport.PolicyReport, automatedExceptions []policyAPI.AutomatedException, conditions []metav1.Condition) recommenderAPI.EnforcementReadinessStatus {
+	status := recommenderAPI.EnforcementReadinessStatus{
+		Mode:       policyManifest.Spec.Mode,
+		Conditions: append([]metav1.Condition{}, conditions...),
+	}
+
+	// Workloads with an AutomatedException for the policy, as namespace/kind/name
+	covered := make(map[string]bool)
+	now := time.Now()
+	for _, automatedException := range automatedExceptions {
+		if automatedException.Labels[utils.AppLabelName] != utils.ComponentName || utils.IsExpired(automatedException.Annotations, now) {
+			continue
+		}
+		for _, policy := range automatedException.Spec.Policies {
+			if policy == policyManifest.Name {
+				labels := automatedException.Labels
+				covered[workloadKey(labels[utils.NamespaceLabelName], labels[utils.KindLabelName], labels[utils.NameLabelName])] = true
+			}
+		}
+	}
+
+	failing := make(map[types.UID]string)
+	for _, policyReport := range policyReports {
+		if policyReport.Scope == nil {
+			continue
+		}
+		for _, result := range policyReport.Results {
+			if result.Policy == policyManifest.Name && result.Result == "fail" {
+				failing[policyReport.Scope.UID] = workloadKey(policyReport.Scope.Namespace, policyReport.Scope.Kind, policyReport.Scope.Name)
+			}
+		}
+	}
+
+	var uncovered []string
+	for _, workload := range failing {
+		status.OutstandingFailures++
+		if covered[workload] {
+			status.CoveredFailures++
+		} else {
+			uncovered = append(uncovered, workload)
+		}
+	}
+	sort.Strings(uncovered)
+	if len(uncovered) > maxUncoveredWorkloads {
+		uncovered = uncovered[:maxUncoveredWorkloads]
+	}
+	status.UncoveredWorkloads = uncovered
+
+	condition := metav1.Condition{
+		Type:   recommenderAPI.ReadyToEnforceCondition,
+		Status: metav1.ConditionTrue,
+		Reason: ReasonNoFailures,
+	}
+	switch {
+	case status.OutstandingFailures == 0:
+		condition.Message = "No workload fails the policy"
+	case status.CoveredFailures == status.OutstandingFailures:
+		condition.Reason = ReasonFailuresCovered
+		condition.Message = fmt.Sprintf("All %d failing workloads have an AutomatedException", status.OutstandingFailures)
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonUncoveredFailures
+		condition.Message = fmt.Sprintf("%d of %d failing workloads have no AutomatedException", status.OutstandingFailures-status.CoveredFailures, status.OutstandingFailures)
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	return status
+}
+
+func workloadKey(namespace string, kind string, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+func setReadinessMetrics(policy string, status recommenderAPI.EnforcementReadinessStatus) {
+	// Drop the series of the previous mode
+	deleteReadinessMetrics(policy)
+
+	OutstandingFailuresMetric.WithLabelValues(policy, status.Mode).Set(float64(status.OutstandingFailures))
+	CoveredFailuresMetric.WithLabelValues(policy, status.Mode).Set(float64(status.CoveredFailures))
+	ready := 0.0
+	if meta.IsStatusConditionTrue(status.Conditions, recommenderAPI.ReadyToEnforceCondition) {
+		ready = 1
+	}
+	ReadyToEnforceMetric.WithLabelValues(policy, status.Mode).Set(ready)
+}
+
+func deleteReadinessMetrics(policy string) {
+	OutstandingFailuresMetric.DeletePartialMatch(prometheus.Labels{"policy": policy})
+	CoveredFailuresMetric.DeletePartialMatch(prometheus.Labels{"policy": policy})
+	ReadyToEnforceMetric.DeletePartialMatch(prometheus.Labels{"policy": policy})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EnforcementReadinessReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&policyAPI.PolicyManifest{}).
+		Owns(&recommenderAPI.EnforcementReadiness{}).
+		// Assess the policies of the changed PolicyReport
+		Watches(&policyreport.PolicyReport{}, handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+			policyReport, ok := obj.(*policyreport.PolicyReport)
+			if !ok {
+				return nil
+			}
+			policies := make([]string, 0, len(policyReport.Results))
+			for _, result := range policyReport.Results {
+				policies = append(policies, result.Policy)
+			}
+			return readinessRequests(policies)
+		})).
+		// Assess the policies of the changed AutomatedException
+		Watches(&policyAPI.AutomatedException{}, handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+			automatedException, ok := obj.(*policyAPI.AutomatedException)
+			if !ok {
+				return nil
+			}
+			return readinessRequests(automatedException.Spec.Policies)
+		})).
+		Named("enforcementreadiness").
+		WithLogConstructor(logConstructor(r.Log, mgr, "enforcementreadiness", "policymanifest")).
+		Complete(r)
+}
+
+func readinessRequests(policies []string) []reconcile.Request {
+	seen := make(map[string]bool)
+	requests := []reconcile.Request{}
+	for _, policy := range policies {
+		if policy == "" || seen[policy] {
+			continue
+		}
+		seen[policy] = true
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: policy}})
+	}
+	return requests
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"time"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("EnforcementReadiness", func() {
+	const Policy = "require-seccomp-profile"
+
+	policyManifest := policyAPI.PolicyManifest{
+		ObjectMeta: metav1.ObjectMeta{Name: Policy},
+		Spec:       policyAPI.PolicyManifestSpec{Mode: ManifestExpectedMode},
+	}
+	failing := func(namespace string, name string) policyreport.PolicyReport {
+		return policyreport.PolicyReport{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Scope:      &corev1.ObjectReference{Kind: "Deployment", Name: name, Namespace: namespace, UID: types.UID(namespace + name)},
+			Results:    []policyreport.PolicyReportResult{{Policy: Policy, Result: "fail"}},
+		}
+	}
+	excepted := func(namespace string, name string) policyAPI.AutomatedException {
+		return policyAPI.AutomatedException{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "policy-exceptions",
+				Labels:    map[string]string{utils.AppLabelName: utils.ComponentName, utils.NamespaceLabelName: namespace, utils.KindLabelName: "Deployment", utils.NameLabelName: name},
+			},
+			Spec: policyAPI.AutomatedExceptionSpec{Policies: []string{Policy}},
+		}
+	}
+
+	It("is ready without failures", func() {
+		status := AssessEnforcementReadiness(policyManifest, nil, nil, nil)
+
+		Expect(status.OutstandingFailures).To(BeZero())
+		condition := meta.FindStatusCondition(status.Conditions, recommenderAPI.ReadyToEnforceCondition)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ReasonNoFailures))
+	})
+
+	It("is ready when every failure is covered by an AutomatedException", func() {
+		status := AssessEnforcementReadiness(policyManifest,
+			[]policyreport.PolicyReport{failing("team-a", "api"), failing("team-b", "api")},
+			[]policyAPI.AutomatedException{excepted("team-a", "api"), excepted("team-b", "api")},
+			nil)
+
+		Expect(status.OutstandingFailures).To(Equal(int32(2)))
+		Expect(status.CoveredFailures).To(Equal(int32(2)))
+		Expect(status.UncoveredWorkloads).To(BeEmpty())
+		Expect(meta.IsStatusConditionTrue(status.Conditions, recommenderAPI.ReadyToEnforceCondition)).To(BeTrue())
+	})
+
+	It("lists the uncovered workloads", func() {
+		status := AssessEnforcementReadiness(policyManifest,
+			[]policyreport.PolicyReport{failing("team-a", "api"), failing("team-b", "api")},
+			// The AutomatedException of another workload with the same name doesn't count
+			[]policyAPI.AutomatedException{excepted("team-a", "api")},
+			nil)
+
+		Expect(status.CoveredFailures).To(Equal(int32(1)))
+		Expect(status.UncoveredWorkloads).To(Equal([]string{"team-b/Deployment/api"}))
+		condition := meta.FindStatusCondition(status.Conditions, recommenderAPI.ReadyToEnforceCondition)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ReasonUncoveredFailures))
+	})
+
+	It("doesn't count expired and unmanaged AutomatedExceptions", func() {
+		marked := excepted("team-a", "api")
+		marked.Annotations = map[string]string{utils.ExpiredAnnotation: "true"}
+		pastExpiry := excepted("team-a", "web")
+		pastExpiry.Annotations = map[string]string{utils.ExpiresAtAnnotation: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)}
+		unmanaged := excepted("team-a", "worker")
+		delete(unmanaged.Labels, utils.AppLabelName)
+		valid := excepted("team-a", "db")
+		valid.Annotations = map[string]string{utils.ExpiresAtAnnotation: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}
+
+		status := AssessEnforcementReadiness(policyManifest,
+			[]policyreport.PolicyReport{failing("team-a", "api"), failing("team-a", "web"), failing("team-a", "worker"), failing("team-a", "db")},
+			[]policyAPI.AutomatedException{marked, pastExpiry, unmanaged, valid},
+			nil)
+
+		Expect(status.OutstandingFailures).To(Equal(int32(4)))
+		Expect(status.CoveredFailures).To(Equal(int32(1)))
+		Expect(status.UncoveredWorkloads).To(Equal([]string{"team-a/Deployment/api", "team-a/Deployment/web", "team-a/Deployment/worker"}))
+	})
+
+	It("keeps the transition time of an unchanged condition", func() {
+		transition := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+		status := AssessEnforcementReadiness(policyManifest, nil, nil, []metav1.Condition{{
+			Type:               recommenderAPI.ReadyToEnforceCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             ReasonNoFailures,
+			LastTransitionTime: transition,
+		}})
+
+		Expect(status.Conditions).To(ConsistOf(HaveField("LastTransitionTime", transition)))
+	})
+
+	When("a PolicyManifest is created", func() {
+		It("maintains its EnforcementReadiness", func() {
+			if k8sClient == nil {
+				Skip("requires the test environment")
+			}
+
+			manifest := policyManifest.DeepCopy()
+			manifest.Name = "readiness-policy"
+			Expect(k8sClient.Create(ctx, manifest)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				var readiness recommenderAPI.EnforcementReadiness
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: manifest.Name}, &readiness)).To(Succeed())
+				g.Expect(meta.IsStatusConditionTrue(readiness.Status.Conditions, recommenderAPI.ReadyToEnforceCondition)).To(BeTrue())
+			}, 10*time.Second, 250*time.Millisecond).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// ModeMissing groups the policies without PolicyManifest in the compliance report
+const ModeMissing = "missing"
+
+// ComplianceReport groups the AutomatedExceptions of the cluster by policy, namespace and PolicyManifest mode.
+type ComplianceReport struct {
+	GeneratedAt time.Time             `json:"generatedAt"`
+	Policies    []PolicyCompliance    `json:"policies"`
+	Namespaces  []NamespaceCompliance `json:"namespaces"`
+	Modes       []ModeCompliance      `json:"modes"`
+}
+
+// PolicyCompliance is the state of a single policy.
+type PolicyCompliance struct {
+	Policy              string   `json:"policy"`
+	Mode                string   `json:"mode"`
+	AutomatedExceptions int      `json:"automatedExceptions"`
+	Namespaces          []string `json:"namespaces"`
+	// WorkloadsBreakingOnEnforce is the number of workloads failing the policy, which would break
+	// if the policy went from warming to enforce. It is only computed for policies in warming mode.
+	WorkloadsBreakingOnEnforce int `json:"workloadsBreakingOnEnforce"`
+}
+
+// NamespaceCompliance is the state of the workloads of a namespace.
+type NamespaceCompliance struct {
+	Namespace           string   `json:"namespace"`
+	AutomatedExceptions int      `json:"automatedExceptions"`
+	Policies            []string `json:"policies"`
+}
+
+// ModeCompliance is the state of the policies in a PolicyManifest mode.
+type ModeCompliance struct {
+	Mode                string `json:"mode"`
+	Policies            int    `json:"policies"`
+	AutomatedExceptions int    `json:"automatedExceptions"`
+}
+
+// BuildComplianceReport aggregates the AutomatedExceptions, PolicyReports and PolicyManifests into a ComplianceReport.
+func BuildComplianceReport(automatedExceptions []policyAPI.AutomatedException, policyReports []policyreport.PolicyReport, policyManifests []policyAPI.PolicyManifest) ComplianceReport {
+	modes := make(map[string]string)
+	for _, policyManifest := range policyManifests {
+		modes[policyManifest.Name] = policyManifest.Spec.Mode
+	}
+	mode := func(policy string) string {
+		if mode, ok := modes[policy]; ok && mode != "" {
+			return mode
+		}
+		return ModeMissing
+	}
+
+	policies := make(map[string]*PolicyCompliance)
+	policy := func(name string) *PolicyCompliance {
+		if policies[name] == nil {
+			policies[name] = &PolicyCompliance{Policy: name, Mode: mode(name)}
+		}
+		return policies[name]
+	}
+	namespaces := make(map[string]*NamespaceCompliance)
+	policyNamespaces := make(map[string]map[string]bool)
+	namespacePolicies := make(map[string]map[string]bool)
+
+	for _, automatedException := range automatedExceptions {
+		// Group by the namespace of the workload, AutomatedExceptions may live in a destination namespace
+		namespace := automatedException.Labels[utils.NamespaceLabelName]
+		if namespace == "" {
+			namespace = automatedException.Namespace
+		}
+		if namespaces[namespace] == nil {
+			namespaces[namespace] = &NamespaceCompliance{Namespace: namespace}
+			namespacePolicies[namespace] = make(map[string]bool)
+		}
+		namespaces[namespace].AutomatedExceptions++
+
+		for _, name := range automatedException.Spec.Policies {
+			policy(name).AutomatedExceptions++
+			if policyNamespaces[name] == nil {
+				policyNamespaces[name] = make(map[string]bool)
+			}
+			policyNamespaces[name][namespace] = true
+			namespacePolicies[namespace][name] = true
+		}
+	}
+
+	// Count the workloads failing each warming policy
+	breaking := make(map[string]map[types.UID]bool)
+	for _, policyReport := range policyReports {
+		if policyReport.Scope == nil {
+			continue
+		}
+		for _, result := range policyReport.Results {
+			if result.Result != "fail" || mode(result.Policy) != ManifestExpectedMode {
+				continue
+			}
+			if breaking[result.Policy] == nil {
+				breaking[result.Policy] = make(map[types.UID]bool)
+			}
+			breaking[result.Policy][policyReport.Scope.UID] = true
+		}
+	}
+	for name, workloads := range breaking {
+		policy(name).WorkloadsBreakingOnEnforce = len(workloads)
+	}
+
+	report := ComplianceReport{
+		GeneratedAt: time.Now().UTC(),
+		Policies:    []PolicyCompliance{},
+		Namespaces:  []NamespaceCompliance{},
+		Modes:       []ModeCompliance{},
+	}
+
+	modeCompliance := make(map[string]*ModeCompliance)
+	for name, compliance := range policies {
+		compliance.Namespaces = sortedKeys(policyNamespaces[name])
+		report.Policies = append(report.Policies, *compliance)
+
+		if modeCompliance[compliance.Mode] == nil {
+			modeCompliance[compliance.Mode] = &ModeCompliance{Mode: compliance.Mode}
+		}
+		modeCompliance[compliance.Mode].Policies++
+		modeCompliance[compliance.Mode].AutomatedExceptions += compliance.AutomatedExceptions
+	}
+	// Policies blocking enforcement the most come first
+	sort.Slice(report.Policies, func(i, j int) bool {
+		if report.Policies[i].WorkloadsBreakingOnEnforce != report.Policies[j].WorkloadsBreakingOnEnforce {
+			return report.Policies[i].WorkloadsBreakingOnEnforce > report.Policies[j].WorkloadsBreakingOnEnforce
+		}
+		return report.Policies[i].Policy < report.Policies[j].Policy
+	})
+
+	for name, compliance := range namespaces {
+		compliance.Policies = sortedKeys(namespacePolicies[name])
+		report.Namespaces = append(report.Namespaces, *compliance)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+
+	for _, compliance := range modeCompliance {
+		report.Modes = append(report.Modes, *compliance)
+	}
+	sort.Slice(report.Modes, func(i, j int) bool {
+		return report.Modes[i].Mode < report.Modes[j].Mode
+	})
+
+	return report
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ComplianceReportHandler serves the ComplianceReport of the live cluster state over HTTP, counting the
+// AutomatedExceptions managed by the recommender only.
+// It renders HTML unless JSON is requested with the format=json query parameter or the Accept header.
+type ComplianceReportHandler struct {
+	Client client.Reader
+}
+
+func (h *ComplianceReportHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var automatedExceptions policyAPI.AutomatedExceptionList
+	if err := h.Client.List(req.Context(), &automatedExceptions, client.MatchingLabels{utils.AppLabelName: utils.ComponentName}); err != nil {
+		http.Error(w, fmt.Sprintf("unable to list AutomatedExceptions: %s", err), http.StatusInternalServerError)
+		return
+	}
+	var policyReports policyreport.PolicyReportList
+	if err := h.Client.List(req.Context(), &policyReports); err != nil {
+		http.Error(w, fmt.Sprintf("unable to list PolicyReports: %s", err), http.StatusInternalServerError)
+		return
+	}
+	var policyManifests policyAPI.PolicyManifestList
+	if err := h.Client.List(req.Context(), &policyManifests); err != nil {
+		http.Error(w, fmt.Sprintf("unable to list PolicyManifests: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	report := BuildComplianceReport(automatedExceptions.Items, policyReports.Items, policyManifests.Items)
+
+	var err error
+	if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(report)
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = complianceReportTemplate.Execute(w, report)
+	}
+	if err != nil {
+		log.FromContext(req.Context()).Error(err, "unable to write compliance report")
+	}
+}
+
+var complianceReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Exception recommender compliance report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<h1>Compliance report</h1>
+<p>Generated at {{ .GeneratedAt.Format "2006-01-02T15:04:05Z07:00" }}, also available as <a href="?format=json">JSON</a>.</p>
+
+<h2>Policies</h2>
+<table>
+<tr><th>Policy</th><th>Mode</th><th>AutomatedExceptions</th><th>Workloads breaking on enforce</th><th>Namespaces</th></tr>
+{{- range .Policies }}
+<tr><td>{{ .Policy }}</td><td>{{ .Mode }}</td><td>{{ .AutomatedExceptions }}</td><td>{{ if eq .Mode "warming" }}{{ .WorkloadsBreakingOnEnforce }}{{ else }}-{{ end }}</td><td>{{ range $i, $namespace := .Namespaces }}{{ if $i }}, {{ end }}{{ $namespace }}{{ end }}</td></tr>
+{{- end }}
+</table>
+
+<h2>Namespaces</h2>
+<table>
+<tr><th>Namespace</th><th>AutomatedExceptions</th><th>Policies</th></tr>
+{{- range .Namespaces }}
+<tr><td>{{ .Namespace }}</td><td>{{ .AutomatedExceptions }}</td><td>{{ range $i, $policy := .Policies }}{{ if $i }}, {{ end }}{{ $policy }}{{ end }}</td></tr>
+{{- end }}
+</table>
+
+<h2>PolicyManifest modes</h2>
+<table>
+<tr><th>Mode</th><th>Policies</th><th>AutomatedExceptions</th></tr>
+{{- range .Modes }}
+<tr><td>{{ .Mode }}</td><td>{{ .Policies }}</td><td>{{ .AutomatedExceptions }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
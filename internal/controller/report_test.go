@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("Compliance report", func() {
+	automatedException := func(workload string, namespace string, policies ...string) *policyAPI.AutomatedException {
+		return &policyAPI.AutomatedException{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      workload,
+				Namespace: "policy-exceptions",
+				Labels:    map[string]string{utils.AppLabelName: utils.ComponentName, utils.NamespaceLabelName: namespace, utils.NameLabelName: workload},
+			},
+			Spec: policyAPI.AutomatedExceptionSpec{Policies: policies},
+		}
+	}
+	failing := func(workload string, namespace string, policies ...string) *policyreport.PolicyReport {
+		policyReport := &policyreport.PolicyReport{
+			ObjectMeta: metav1.ObjectMeta{Name: workload, Namespace: namespace},
+			Scope:      &corev1.ObjectReference{Kind: "Deployment", Name: workload, Namespace: namespace, UID: types.UID(workload)},
+		}
+		for _, policy := range policies {
+			policyReport.Results = append(policyReport.Results, policyreport.PolicyReportResult{Policy: policy, Result: "fail"})
+		}
+		return policyReport
+	}
+	manifest := func(policy string, mode string) *policyAPI.PolicyManifest {
+		return &policyAPI.PolicyManifest{
+			ObjectMeta: metav1.ObjectMeta{Name: policy},
+			Spec:       policyAPI.PolicyManifestSpec{Mode: mode},
+		}
+	}
+
+	It("groups AutomatedExceptions by policy, namespace and mode", func() {
+		report := BuildComplianceReport(
+			[]policyAPI.AutomatedException{
+				*automatedException("api", "team-a", "require-run-as-nonroot", "disallow-privilege-escalation"),
+				*automatedException("worker", "team-b", "require-run-as-nonroot"),
+			},
+			[]policyreport.PolicyReport{
+				*failing("api", "team-a", "require-run-as-nonroot", "disallow-privilege-escalation"),
+				*failing("worker", "team-b", "require-run-as-nonroot"),
+				*failing("web", "team-b", "require-run-as-nonroot", "restrict-seccomp"),
+			},
+			[]policyAPI.PolicyManifest{
+				*manifest("require-run-as-nonroot", ManifestExpectedMode),
+				*manifest("disallow-privilege-escalation", ManifestExpectedMode),
+				*manifest("restrict-seccomp", "enforce"),
+			},
+		)
+
+		Expect(report.Policies).To(Equal([]PolicyCompliance{
+			{Policy: "require-run-as-nonroot", Mode: ManifestExpectedMode, AutomatedExceptions: 2, Namespaces: []string{"team-a", "team-b"}, WorkloadsBreakingOnEnforce: 3},
+			{Policy: "disallow-privilege-escalation", Mode: ManifestExpectedMode, AutomatedExceptions: 1, Namespaces: []string{"team-a"}, WorkloadsBreakingOnEnforce: 1},
+		}))
+		Expect(report.Namespaces).To(Equal([]NamespaceCompliance{
+			{Namespace: "team-a", AutomatedExceptions: 1, Policies: []string{"disallow-privilege-escalation", "require-run-as-nonroot"}},
+			{Namespace: "team-b", AutomatedExceptions: 1, Policies: []string{"require-run-as-nonroot"}},
+		}))
+		Expect(report.Modes).To(Equal([]ModeCompliance{{Mode: ManifestExpectedMode, Policies: 2, AutomatedExceptions: 3}}))
+	})
+
+	It("reports policies without PolicyManifest as missing", func() {
+		report := BuildComplianceReport([]policyAPI.AutomatedException{*automatedException("api", "team-a", "require-labels")}, nil, nil)
+
+		Expect(report.Policies).To(ConsistOf(HaveField("Mode", ModeMissing)))
+	})
+
+	Describe("serving the report", func() {
+		var handler *ComplianceReportHandler
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(policyAPI.AddToScheme(scheme)).To(Succeed())
+			Expect(policyreport.AddToScheme(scheme)).To(Succeed())
+			handler = &ComplianceReportHandler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				automatedException("api", "team-a", "require-run-as-nonroot"),
+				failing("api", "team-a", "require-run-as-nonroot"),
+				manifest("require-run-as-nonroot", ManifestExpectedMode),
+			).Build()}
+		})
+
+		It("renders JSON on request", func() {
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, httptest.NewRequest("GET", "/report?format=json", nil))
+
+			Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+			var report ComplianceReport
+			Expect(json.Unmarshal(response.Body.Bytes(), &report)).To(Succeed())
+			Expect(report.Policies).To(ConsistOf(HaveField("WorkloadsBreakingOnEnforce", 1)))
+		})
+
+		It("renders HTML by default", func() {
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, httptest.NewRequest("GET", "/report", nil))
+
+			Expect(response.Header().Get("Content-Type")).To(HavePrefix("text/html"))
+			Expect(response.Body.String()).To(ContainSubstring("<td>require-run-as-nonroot</td><td>warming</td><td>1</td><td>1</td><td>team-a</td>"))
+		})
+
+		It("ignores AutomatedExceptions not managed by the recommender", func() {
+			unmanaged := automatedException("web", "team-b", "require-run-as-nonroot")
+			delete(unmanaged.Labels, utils.AppLabelName)
+			Expect(handler.Client.(client.Client).Create(context.Background(), unmanaged)).To(Succeed())
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, httptest.NewRequest("GET", "/report?format=json", nil))
+
+			var report ComplianceReport
+			Expect(json.Unmarshal(response.Body.Bytes(), &report)).To(Succeed())
+			Expect(report.Policies).To(ConsistOf(HaveField("Namespaces", ConsistOf("team-a"))))
+		})
+	})
+})
@@ -1,20 +1,177 @@
 package controller
 
 import (
+	"errors"
+
 	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
+// Failure reasons of the ReconciliationFailuresMetric
+const (
+	FailureFetch           = "fetch"
+	FailureCreate          = "create"
+	FailurePatch           = "patch"
+	FailureDelete          = "delete"
+	FailureManifestMissing = "manifest_missing"
+	FailureConflict        = "conflict"
+)
+
 var (
 	ReconciliationFailuresMetric = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "exception_recommender_reconciliation_failures_total",
-			Help: "Number of failed reconciliations",
-		}, []string{"resource_type"},
+			Help: "Number of failed reconciliations by reason",
+		}, []string{"resource_type", "reason"},
+	)
+	AutomatedExceptionsMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_automated_exceptions",
+			Help: "Number of AutomatedExceptions by workload namespace, kind, policy and category",
+		}, []string{"namespace", "kind", "policy", "category"},
+	)
+	OperationsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_operations_total",
+			Help: "Number of create, update, delete and unchanged operations on recommender resources",
+		}, []string{"kind", "operation"},
+	)
+	CachedPolicyManifestsMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_cached_policy_manifests",
+			Help: "Number of cached PolicyManifests by mode",
+		}, []string{"mode"},
+	)
+	TimeToExceptionMetric = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "exception_recommender_time_to_exception_seconds",
+			Help:    "Time from the earliest failed result of a workload to the creation of its AutomatedException",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 10),
+		},
+	)
+	ExpiryWarningsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_expiry_warnings_total",
+			Help: "Number of AutomatedExceptions which entered the expiry warning window",
+		}, []string{"namespace"},
+	)
+	ExpiredExceptionsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_expired_exceptions_total",
+			Help: "Number of expired AutomatedExceptions",
+		}, []string{"namespace", "action"},
+	)
+	SuppressedDeletionsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_suppressed_deletions_total",
+			Help: "Number of AutomatedException deletions delayed by the deletion grace period",
+		}, []string{"namespace"},
+	)
+	DryRunOperationsMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_dry_run_operations",
+			Help: "Number of AutomatedException operations planned in dry-run mode",
+		}, []string{"operation"},
+	)
+	RenewalsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_renewals_total",
+			Help: "Number of AutomatedException renewal requests",
+		}, []string{"namespace", "result"},
+	)
+	ProtectedPolicySkipsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_protected_policy_skips_total",
+			Help: "Number of failed protected policies for which no AutomatedException was drafted",
+		}, []string{"namespace", "policy"},
+	)
+	LimitedCreationsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_limited_creations_total",
+			Help: "Number of AutomatedException creations prevented by a limit",
+		}, []string{"limit"},
+	)
+	CircuitBreakerOpenMetric = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_circuit_breaker_open",
+			Help: "Whether the creation of AutomatedExceptions is paused by the circuit breaker",
+		},
+	)
+	WriteThrottleMetric = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "exception_recommender_write_throttle_seconds",
+			Help:    "Time AutomatedException writes waited for the write rate limiter",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+	)
+	MissingPolicyManifestsMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_policy_manifest_missing",
+			Help: "Whether failed results of the Policy are waiting for its PolicyManifest",
+		}, []string{"policy"},
+	)
+	DriftCorrectionsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_drift_corrections_total",
+			Help: "Number of AutomatedExceptions restored after being deleted or modified by others, by workload namespace",
+		}, []string{"namespace", "drift"},
+	)
+	OutstandingFailuresMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_enforcement_outstanding_failures",
+			Help: "Number of workloads failing the policy of a PolicyManifest",
+		}, []string{"policy", "mode"},
+	)
+	CoveredFailuresMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_enforcement_covered_failures",
+			Help: "Number of workloads failing the policy of a PolicyManifest which have an AutomatedException for it",
+		}, []string{"policy", "mode"},
+	)
+	ReadyToEnforceMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_enforcement_ready",
+			Help: "Whether enforcing the policy of a PolicyManifest would not break any workload",
+		}, []string{"policy", "mode"},
 	)
 )
 
 func init() {
 	// Register custom metrics with the global prometheus registry
-	metrics.Registry.MustRegister(ReconciliationFailuresMetric)
+	metrics.Registry.MustRegister(
+		ReconciliationFailuresMetric,
+		AutomatedExceptionsMetric,
+		OperationsMetric,
+		CachedPolicyManifestsMetric,
+		TimeToExceptionMetric,
+		ExpiryWarningsMetric,
+		ExpiredExceptionsMetric,
+		RenewalsMetric,
+		SuppressedDeletionsMetric,
+		DryRunOperationsMetric,
+		ProtectedPolicySkipsMetric,
+		LimitedCreationsMetric,
+		CircuitBreakerOpenMetric,
+		DriftCorrectionsMetric,
+		MissingPolicyManifestsMetric,
+		WriteThrottleMetric,
+		OutstandingFailuresMetric,
+		CoveredFailuresMetric,
+		ReadyToEnforceMetric,
+	)
+}
+
+// countFailure increments the ReconciliationFailuresMetric. Conflicts are counted as such whatever the failed
+// API call, and errors returned by CreateOrUpdate carry the reason of the call which failed.
+func countFailure(resourceType string, reason string, err error) {
+	var operationErr *operationError
+	switch {
+	case apierrors.IsConflict(err):
+		reason = FailureConflict
+	case errors.As(err, &operationErr):
+		reason = operationErr.reason
+	}
+
+	ReconciliationFailuresMetric.WithLabelValues(resourceType, reason).Inc()
 }
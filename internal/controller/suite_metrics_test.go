@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("Reconciliation failures", func() {
+	const ResourceType = "FailureTaxonomy"
+
+	resource := schema.GroupResource{Group: "policy.giantswarm.io", Resource: "automatedexceptions"}
+
+	DescribeTable("counting a failure",
+		func(reason string, err error, expectedReason string) {
+			before := testutil.ToFloat64(ReconciliationFailuresMetric.WithLabelValues(ResourceType, expectedReason))
+
+			countFailure(ResourceType, reason, err)
+
+			Expect(testutil.ToFloat64(ReconciliationFailuresMetric.WithLabelValues(ResourceType, expectedReason))).To(Equal(before + 1))
+		},
+		Entry("keeps the given reason", FailureDelete, fmt.Errorf("connection refused"), FailureDelete),
+		Entry("counts missing PolicyManifests without error", FailureManifestMissing, nil, FailureManifestMissing),
+		Entry("uses the reason of the failed CreateOrUpdate call", FailureCreate,
+			&operationError{reason: FailurePatch, err: fmt.Errorf("connection refused")}, FailurePatch),
+		Entry("counts conflicts whatever the call", FailureCreate,
+			&operationError{reason: FailurePatch, err: apierrors.NewConflict(resource, "workload", fmt.Errorf("object was modified"))}, FailureConflict),
+	)
+})
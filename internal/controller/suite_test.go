@@ -32,6 +32,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	tests "github.com/giantswarm/exception-recommender/tests"
@@ -39,6 +40,9 @@ import (
 	wgpolicyk8s "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
 
 	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -52,9 +56,11 @@ var ctx context.Context
 var cancel context.CancelFunc
 var targetCategories = []string{"Pod Security Standards (Restricted)"}
 var targetWorkloads = []string{"Deployment"}
-var policyManifestCache = make(map[string]policyAPI.PolicyManifest)
+var policyManifestCache = NewPolicyManifestCache()
 var destinationNamespace = "default"
-var maxJitterPercent = 10
+var requeue = utils.FixedRequeue{Interval: DefaultRequeueDuration, JitterPercent: 10}
+var policyReportWorkers = 4
+var exceptionWriteQPS, exceptionWriteBurst = float32(200), 100
 
 func TestControllers(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -96,6 +102,10 @@ var _ = BeforeSuite(func() {
 	err = wgpolicyk8s.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	// Add exception-recommender scheme
+	err = recommenderAPI.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	//+kubebuilder:scaffold:scheme
 
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
@@ -107,22 +117,53 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
+	policyManifestChanges := make(chan event.GenericEvent, 100)
 	err = (&PolicyManifestReconciler{
 		Client:              k8sManager.GetClient(),
 		Scheme:              k8sManager.GetScheme(),
 		PolicyManifestCache: policyManifestCache,
-		MaxJitterPercent:    maxJitterPercent,
+		ModeChanges:         policyManifestChanges,
 	}).SetupWithManager(k8sManager)
 	Expect(err).NotTo(HaveOccurred())
 
 	err = (&PolicyReportReconciler{
-		Client:               k8sManager.GetClient(),
-		Scheme:               k8sManager.GetScheme(),
-		DestinationNamespace: destinationNamespace,
-		TargetWorkloads:      targetWorkloads,
-		TargetCategories:     targetCategories,
-		PolicyManifestCache:  policyManifestCache,
-		MaxJitterPercent:     maxJitterPercent,
+		Client:                  NewWriteLimitedClient(k8sManager.GetClient(), exceptionWriteQPS, exceptionWriteBurst),
+		Scheme:                  k8sManager.GetScheme(),
+		DestinationNamespace:    destinationNamespace,
+		TargetWorkloads:         targetWorkloads,
+		TargetCategories:        targetCategories,
+		PolicyManifestCache:     policyManifestCache,
+		Requeue:                 requeue,
+		PolicyManifestChanges:   policyManifestChanges,
+		MaxConcurrentReconciles: policyReportWorkers,
+	}).SetupWithManager(k8sManager)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&SummaryReconciler{
+		Client:           k8sManager.GetClient(),
+		Scheme:           k8sManager.GetScheme(),
+		TargetWorkloads:  targetWorkloads,
+		TargetCategories: targetCategories,
+	}).SetupWithManager(k8sManager)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&EnforcementReadinessReconciler{
+		Client: k8sManager.GetClient(),
+		Scheme: k8sManager.GetScheme(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&AutomatedExceptionReconciler{
+		Client: k8sManager.GetClient(),
+		Scheme: k8sManager.GetScheme(),
+	}).SetupWithManager(k8sManager)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&ExpiryReconciler{
+		Client:        k8sManager.GetClient(),
+		Scheme:        k8sManager.GetScheme(),
+		Recorder:      k8sManager.GetEventRecorder("exception-recommender"),
+		WarningWindow: time.Hour,
 	}).SetupWithManager(k8sManager)
 	Expect(err).NotTo(HaveOccurred())
 
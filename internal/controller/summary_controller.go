@@ -0,0 +1,252 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// SummaryName is the name of the ExceptionRecommendationSummary of every namespace
+const SummaryName = "exception-recommendations"
+
+// SummaryReconciler maintains an ExceptionRecommendationSummary per namespace from its PolicyReports
+// and the AutomatedExceptions drafted by the recommender for its workloads.
+type SummaryReconciler struct {
+	client.Client
+	Scheme            *runtime.Scheme
+	Log               logr.Logger
+	ExcludeNamespaces []string
+	TargetWorkloads   []string
+	TargetCategories  []string
+}
+
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=exceptionrecommendationsummaries,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=policy.giantswarm.io,resources=exceptionrecommendationsummaries/status,verbs=get;update;patch
+
+func (r *SummaryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	reconcilerResourceType := "ExceptionRecommendationSummary"
+
+	// Only the summary of the namespace is maintained
+	if req.Name != SummaryName {
+		return ctrl.Result{}, nil
+	}
+	for _, namespace := range r.ExcludeNamespaces {
+		if namespace == req.Namespace {
+			return ctrl.Result{}, nil
+		}
+	}
+
+	var policyReports policyreport.PolicyReportList
+	if err := r.List(ctx, &policyReports, client.InNamespace(req.Namespace)); err != nil {
+		logger.Error(err, "unable to list PolicyReports")
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
+	}
+
+	var automatedExceptions policyAPI.AutomatedExceptionList
+	if err := r.List(ctx, &automatedExceptions, client.MatchingLabels{utils.AppLabelName: utils.ComponentName, utils.NamespaceLabelName: req.Namespace}); err != nil {
+		logger.Error(err, "unable to list AutomatedExceptions")
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
+	}
+
+	status := r.Summarize(policyReports.Items, automatedExceptions.Items)
+
+	var summary recommenderAPI.ExceptionRecommendationSummary
+	err := r.Get(ctx, req.NamespacedName, &summary)
+	switch {
+	case errors.IsNotFound(err):
+		if status.Workloads == 0 && len(status.DraftedExceptions) == 0 {
+			// Nothing to summarize
+			return ctrl.Result{}, nil
+		}
+		summary = recommenderAPI.ExceptionRecommendationSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      req.Name,
+				Namespace: req.Namespace,
+				Labels:    map[string]string{utils.AppLabelName: utils.ComponentName},
+			},
+		}
+		if err := r.Create(ctx, &summary); err != nil {
+			logger.Error(err, "unable to create ExceptionRecommendationSummary")
+			countFailure(reconcilerResourceType, FailureCreate, err)
+			return ctrl.Result{}, err
+		}
+		OperationsMetric.WithLabelValues(reconcilerResourceType, CreateOp).Inc()
+	case err != nil:
+		logger.Error(err, "unable to fetch ExceptionRecommendationSummary")
+		countFailure(reconcilerResourceType, FailureFetch, err)
+		return ctrl.Result{}, err
+	case status.Workloads == 0 && len(status.DraftedExceptions) == 0:
+		// The namespace no longer has any workload to summarize
+		if err := r.Delete(ctx, &summary); err != nil {
+			logger.Error(err, "unable to delete ExceptionRecommendationSummary")
+			countFailure(reconcilerResourceType, FailureDelete, err)
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		OperationsMetric.WithLabelValues(reconcilerResourceType, DeleteOp).Inc()
+		return ctrl.Result{}, nil
+	}
+
+	if equality.Semantic.DeepEqual(summary.Status, status) {
+		logger.V(DebugLevel).Info("ExceptionRecommendationSummary is up to date")
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFrom(summary.DeepCopy())
+	summary.Status = status
+	if err := r.Status().Patch(ctx, &summary, patch); err != nil {
+		logger.Error(err, "unable to update ExceptionRecommendationSummary status")
+		countFailure(reconcilerResourceType, FailurePatch, err)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	OperationsMetric.WithLabelValues(reconcilerResourceType, UpdateOp).Inc()
+	logger.Info("Updated ExceptionRecommendationSummary", "failingWorkloads", status.FailingWorkloads, "compliancePercent", status.CompliancePercent)
+
+	return ctrl.Result{}, nil
+}
+
+// Summarize aggregates the failures of the target workloads of the PolicyReports and their AutomatedExceptions.
+func (r *SummaryReconciler) Summarize(policyReports []policyreport.PolicyReport, automatedExceptions []policyAPI.AutomatedException) recommenderAPI.ExceptionRecommendationSummaryStatus {
+	var status recommenderAPI.ExceptionRecommendationSummaryStatus
+
+	workloads := make(map[types.UID]bool)
+	// Failing workloads per policy and category
+	policyWorkloads := make(map[string]map[types.UID]bool)
+	categoryWorkloads := make(map[string]map[types.UID]bool)
+	policyCategories := make(map[string]string)
+
+	for _, policyReport := range policyReports {
+		if policyReport.Scope == nil || !isKind(policyReport.Scope.Kind, r.TargetWorkloads) {
+			continue
+		}
+		uid := policyReport.Scope.UID
+		if _, ok := workloads[uid]; !ok {
+			workloads[uid] = false
+		}
+
+		for _, result := range policyReport.Results {
+			if result.Result != "fail" || !isPolicyCategory(result.Category, r.TargetCategories) {
+				continue
+			}
+			workloads[uid] = true
+			addWorkload(policyWorkloads, result.Policy, uid)
+			addWorkload(categoryWorkloads, result.Category, uid)
+			policyCategories[result.Policy] = result.Category
+		}
+	}
+
+	status.Workloads = int32(len(workloads))
+	for _, failing := range workloads {
+		if failing {
+			status.FailingWorkloads++
+		}
+	}
+	status.CompliancePercent = 100
+	if status.Workloads > 0 {
+		status.CompliancePercent = (status.Workloads - status.FailingWorkloads) * 100 / status.Workloads
+	}
+
+	for policy, failing := range policyWorkloads {
+		status.Policies = append(status.Policies, recommenderAPI.PolicySummary{
+			Policy:           policy,
+			Category:         policyCategories[policy],
+			FailingWorkloads: int32(len(failing)),
+		})
+	}
+	sort.Slice(status.Policies, func(i, j int) bool {
+		return status.Policies[i].Policy < status.Policies[j].Policy
+	})
+
+	for category, failing := range categoryWorkloads {
+		status.Categories = append(status.Categories, recommenderAPI.CategorySummary{
+			Category:         category,
+			FailingWorkloads: int32(len(failing)),
+		})
+	}
+	sort.Slice(status.Categories, func(i, j int) bool {
+		return status.Categories[i].Category < status.Categories[j].Category
+	})
+
+	for _, automatedException := range automatedExceptions {
+		status.DraftedExceptions = append(status.DraftedExceptions, recommenderAPI.DraftedException{
+			Name:         automatedException.Name,
+			Namespace:    automatedException.Namespace,
+			Kind:         automatedException.Labels[utils.KindLabelName],
+			WorkloadName: automatedException.Labels[utils.NameLabelName],
+			Policies:     automatedException.Spec.Policies,
+		})
+	}
+	sort.Slice(status.DraftedExceptions, func(i, j int) bool {
+		if status.DraftedExceptions[i].Kind != status.DraftedExceptions[j].Kind {
+			return status.DraftedExceptions[i].Kind < status.DraftedExceptions[j].Kind
+		}
+		return status.DraftedExceptions[i].WorkloadName < status.DraftedExceptions[j].WorkloadName
+	})
+
+	return status
+}
+
+func addWorkload(workloads map[string]map[types.UID]bool, key string, uid types.UID) {
+	if workloads[key] == nil {
+		workloads[key] = make(map[types.UID]bool)
+	}
+	workloads[key][uid] = true
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SummaryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&recommenderAPI.ExceptionRecommendationSummary{}).
+		// Summarize the namespace of the changed PolicyReport
+		Watches(&policyreport.PolicyReport{}, handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+			return summaryRequest(obj.GetNamespace())
+		})).
+		// Summarize the namespace of the workload of the changed AutomatedException
+		Watches(&policyAPI.AutomatedException{}, handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+			return summaryRequest(obj.GetLabels()[utils.NamespaceLabelName])
+		})).
+		WithLogConstructor(logConstructor(r.Log, mgr, "exceptionrecommendationsummary", "exceptionrecommendationsummary")).
+		Complete(r)
+}
+
+func summaryRequest(namespace string) []reconcile.Request {
+	if namespace == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: SummaryName, Namespace: namespace}}}
+}
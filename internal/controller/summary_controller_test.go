@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("ExceptionRecommendationSummary", func() {
+	const (
+		Namespace = "summary"
+		Category  = "Pod Security Standards (Restricted)"
+	)
+
+	policyReport := func(name string, kind string, results ...policyreport.PolicyReportResult) policyreport.PolicyReport {
+		return policyreport.PolicyReport{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace},
+			Scope:      &corev1.ObjectReference{Kind: kind, Name: name, Namespace: Namespace, UID: types.UID(name)},
+			Results:    results,
+		}
+	}
+	fail := func(policy string, category string) policyreport.PolicyReportResult {
+		return policyreport.PolicyReportResult{Policy: policy, Category: category, Result: "fail"}
+	}
+	pass := func(policy string) policyreport.PolicyReportResult {
+		return policyreport.PolicyReportResult{Policy: policy, Category: Category, Result: "pass"}
+	}
+
+	reconciler := &SummaryReconciler{
+		TargetWorkloads:  []string{"Deployment"},
+		TargetCategories: []string{Category},
+	}
+
+	It("summarizes the failures of the target workloads", func() {
+		status := reconciler.Summarize([]policyreport.PolicyReport{
+			policyReport("api", "Deployment", fail("require-run-as-nonroot", Category), fail("disallow-privilege-escalation", Category)),
+			policyReport("worker", "Deployment", fail("require-run-as-nonroot", Category)),
+			policyReport("web", "Deployment", pass("require-run-as-nonroot")),
+			policyReport("clean", "Deployment", fail("require-labels", "Best Practices")),
+			// Out of scope
+			policyReport("job", "Job", fail("require-run-as-nonroot", Category)),
+		}, []policyAPI.AutomatedException{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "api",
+				Namespace: "policy-exceptions",
+				Labels:    map[string]string{utils.KindLabelName: "Deployment", utils.NameLabelName: "api"},
+			},
+			Spec: policyAPI.AutomatedExceptionSpec{Policies: []string{"require-run-as-nonroot", "disallow-privilege-escalation"}},
+		}})
+
+		Expect(status.Workloads).To(Equal(int32(4)))
+		Expect(status.FailingWorkloads).To(Equal(int32(2)))
+		Expect(status.CompliancePercent).To(Equal(int32(50)))
+		Expect(status.Policies).To(Equal([]recommenderAPI.PolicySummary{
+			{Policy: "disallow-privilege-escalation", Category: Category, FailingWorkloads: 1},
+			{Policy: "require-run-as-nonroot", Category: Category, FailingWorkloads: 2},
+		}))
+		Expect(status.Categories).To(Equal([]recommenderAPI.CategorySummary{{Category: Category, FailingWorkloads: 2}}))
+		Expect(status.DraftedExceptions).To(ConsistOf(HaveField("WorkloadName", "api")))
+	})
+
+	It("is fully compliant without workloads", func() {
+		Expect(reconciler.Summarize(nil, nil).CompliancePercent).To(Equal(int32(100)))
+	})
+
+	It("ignores the AutomatedExceptions not managed by the recommender", func() {
+		automatedException := policyAPI.AutomatedException{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "manual",
+				Namespace: "policy-exceptions",
+				Labels:    map[string]string{utils.KindLabelName: "Deployment", utils.NamespaceLabelName: Namespace, utils.NameLabelName: "manual"},
+			},
+			Spec: policyAPI.AutomatedExceptionSpec{Policies: []string{"require-run-as-nonroot"}},
+		}
+		reconciler := &SummaryReconciler{Client: watchClient(&automatedException)}
+		key := types.NamespacedName{Name: SummaryName, Namespace: Namespace}
+
+		_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		var summary recommenderAPI.ExceptionRecommendationSummary
+		Expect(apierrors.IsNotFound(reconciler.Get(context.Background(), key, &summary))).To(BeTrue())
+	})
+
+	When("a PolicyReport is created", func() {
+		It("maintains the summary of its namespace", func() {
+			if k8sClient == nil {
+				Skip("requires the test environment")
+			}
+
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: Namespace}}
+			Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+
+			report := policyReport("summary-deployment", "Deployment", fail("require-run-as-nonroot", Category))
+			Expect(k8sClient.Create(ctx, &report)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				var summary recommenderAPI.ExceptionRecommendationSummary
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: SummaryName, Namespace: Namespace}, &summary)).To(Succeed())
+				g.Expect(summary.Status.FailingWorkloads).To(Equal(int32(1)))
+				g.Expect(summary.Status.CompliancePercent).To(Equal(int32(0)))
+			}, 10*time.Second, 250*time.Millisecond).Should(Succeed())
+		})
+	})
+})
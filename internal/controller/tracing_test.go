@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	"github.com/giantswarm/exception-recommender/internal/tracing"
+)
+
+var _ = Describe("Tracing", func() {
+	var exporter *tracetest.InMemoryExporter
+
+	BeforeEach(func() {
+		exporter = tracetest.NewInMemoryExporter()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(provider)
+
+		DeferCleanup(func() {
+			otel.SetTracerProvider(previous)
+			Expect(provider.Shutdown(context.Background())).To(Succeed())
+		})
+	})
+
+	spanNames := func() []string {
+		var names []string
+		for _, span := range exporter.GetSpans() {
+			names = append(names, span.Name)
+		}
+		return names
+	}
+
+	It("traces the PolicyManifest lookups of a recommendation", func() {
+		reconciler := &PolicyReportReconciler{
+			TargetWorkloads:     []string{"Deployment"},
+			TargetCategories:    []string{"Pod Security Standards (Restricted)"},
+			PolicyManifestCache: expectedManifests("require-run-as-nonroot"),
+		}
+
+		reconciler.Recommend(context.Background(), policyreport.PolicyReport{
+			ObjectMeta: metav1.ObjectMeta{Name: "tracing", Namespace: "default"},
+			Scope:      &corev1.ObjectReference{Kind: "Deployment", Name: "app", Namespace: "default"},
+			Results: []policyreport.PolicyReportResult{{
+				Policy:   "require-run-as-nonroot",
+				Category: "Pod Security Standards (Restricted)",
+				Result:   "fail",
+			}},
+		}, nil)
+
+		Expect(exporter.GetSpans()).To(ConsistOf(HaveField("Name", "GetPolicyManifestMode")))
+		Expect(exporter.GetSpans()[0].Attributes).To(ContainElements(
+			tracing.PolicyKey.String("require-run-as-nonroot"),
+			tracing.PolicyManifestModeKey.String(ManifestExpectedMode),
+		))
+	})
+
+	It("traces each API call of CreateOrUpdate", func() {
+		scheme := runtime.NewScheme()
+		Expect(policyAPI.AddToScheme(scheme)).To(Succeed())
+		c := Controller{fake.NewClientBuilder().WithScheme(scheme).Build()}
+
+		automatedException := &policyAPI.AutomatedException{
+			TypeMeta:   metav1.TypeMeta{APIVersion: policyAPI.GroupVersion.String(), Kind: "AutomatedException"},
+			ObjectMeta: metav1.ObjectMeta{Name: "e6d75155-e7bd-4df0-84d5-e1b2416cb2b9", Namespace: "policy-exceptions"},
+			Spec:       policyAPI.AutomatedExceptionSpec{Policies: []string{"require-run-as-nonroot"}},
+		}
+
+		// The client clears the kind of the objects it writes, use a fresh copy as the reconciler would
+		op, err := c.CreateOrUpdate(context.Background(), automatedException.DeepCopy())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(op).To(Equal(CreateOp))
+		Expect(spanNames()).To(Equal([]string{"Get", "Create", "CreateOrUpdate"}))
+
+		exporter.Reset()
+		_, err = c.CreateOrUpdate(context.Background(), automatedException.DeepCopy())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spanNames()).To(Equal([]string{"Get", "Patch", "Get", "CreateOrUpdate"}))
+
+		parent := exporter.GetSpans()[3]
+		Expect(parent.Attributes).To(ContainElements(
+			tracing.ObjectKindKey.String("AutomatedException"),
+			tracing.ObjectNameKey.String(automatedException.Name),
+		))
+		Expect(parent.Attributes).To(ContainElement(HaveField("Key", tracing.OperationKey)))
+		for _, span := range exporter.GetSpans()[:3] {
+			Expect(span.Parent.SpanID()).To(Equal(parent.SpanContext.SpanID()))
+		}
+	})
+})
@@ -4,11 +4,14 @@ import (
 	"context"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/exception-recommender/internal/tracing"
 )
 
 // Used for Jitter in requeueing
@@ -19,19 +22,65 @@ const (
 	UpdateOp = "updated"
 	NoOp     = "unchanged"
 	CreateOp = "created"
+	DeleteOp = "deleted"
 )
 
+// operationError records which API call of CreateOrUpdate failed
+type operationError struct {
+	reason string
+	err    error
+}
+
+func (e *operationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *operationError) Unwrap() error {
+	return e.err
+}
+
 type Controller struct {
 	client.Client
 }
 
 // CreateOrUpdate attempts first to patch the object given but if an IsNotFound error
-// is returned it instead creates the resource.
+// is returned it instead creates the resource. Errors carry the failure reason of the failed API call.
 func (r *Controller) CreateOrUpdate(ctx context.Context, obj client.Object) (string, error) {
+	ctx, span := tracing.Start(ctx, "CreateOrUpdate", objectAttributes(obj)...)
+
+	op, err := r.createOrUpdate(ctx, obj)
+	OperationsMetric.WithLabelValues(obj.GetObjectKind().GroupVersionKind().Kind, op).Inc()
+
+	span.SetAttributes(tracing.OperationKey.String(op))
+	tracing.End(span, err)
+
+	return op, err
+}
+
+// traced wraps a single API call on the object in a span.
+func traced(ctx context.Context, name string, obj client.Object, call func(context.Context) error) error {
+	ctx, span := tracing.Start(ctx, name, objectAttributes(obj)...)
+	err := call(ctx)
+	tracing.End(span, err)
+
+	return err
+}
+
+func objectAttributes(obj client.Object) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		tracing.ObjectKindKey.String(obj.GetObjectKind().GroupVersionKind().Kind),
+		tracing.ObjectNamespaceKey.String(obj.GetNamespace()),
+		tracing.ObjectNameKey.String(obj.GetName()),
+	}
+}
+
+func (r *Controller) createOrUpdate(ctx context.Context, obj client.Object) (string, error) {
 	existingObj := unstructured.Unstructured{}
 	existingObj.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
 
-	err := r.Get(ctx, client.ObjectKeyFromObject(obj), &existingObj)
+	err := traced(ctx, "Get", obj, func(ctx context.Context) error {
+		return r.Get(ctx, client.ObjectKeyFromObject(obj), &existingObj)
+	})
 	switch {
 	case err == nil:
 		// Create a deep copy of the existing object before the patch operation
@@ -41,15 +90,19 @@ func (r *Controller) CreateOrUpdate(ctx context.Context, obj client.Object) (str
 		obj.SetResourceVersion(existingObj.GetResourceVersion())
 		obj.SetUID(existingObj.GetUID())
 
-		err = r.Patch(ctx, obj, client.MergeFrom(existingObj.DeepCopy()))
+		err = traced(ctx, "Patch", obj, func(ctx context.Context) error {
+			return r.Patch(ctx, obj, client.MergeFrom(existingObj.DeepCopy()))
+		})
 		if err != nil {
-			return ErrorOp, err
+			return ErrorOp, &operationError{reason: FailurePatch, err: err}
 		}
 
 		// Fetch the object after the patch operation
-		err = r.Get(ctx, client.ObjectKeyFromObject(obj), &existingObj)
+		err = traced(ctx, "Get", obj, func(ctx context.Context) error {
+			return r.Get(ctx, client.ObjectKeyFromObject(obj), &existingObj)
+		})
 		if err != nil {
-			return ErrorOp, err
+			return ErrorOp, &operationError{reason: FailureFetch, err: err}
 		}
 
 		// Compare the object before and after the patch operation
@@ -60,12 +113,14 @@ func (r *Controller) CreateOrUpdate(ctx context.Context, obj client.Object) (str
 		}
 	case errors.IsNotFound(err):
 		// Create:
-		err = r.Create(ctx, obj)
+		err = traced(ctx, "Create", obj, func(ctx context.Context) error {
+			return r.Create(ctx, obj)
+		})
 		if err != nil {
-			return ErrorOp, err
+			return ErrorOp, &operationError{reason: FailureCreate, err: err}
 		}
 		return CreateOp, err
 	default:
-		return ErrorOp, err
+		return ErrorOp, &operationError{reason: FailureFetch, err: err}
 	}
 }
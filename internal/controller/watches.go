@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// Field indexes of the cached PolicyReports
+const (
+	// PolicyReportPolicyIndex indexes the PolicyReports by the Policies of their results
+	PolicyReportPolicyIndex = "results.policy"
+	// PolicyReportScopeUIDIndex indexes the PolicyReports by the UID of their workload
+	PolicyReportScopeUIDIndex = "scope.uid"
+)
+
+// WorkloadKinds maps the workload kinds which can be watched to their GroupVersionKind
+var WorkloadKinds = map[string]schema.GroupVersionKind{
+	"Pod":         corev1.SchemeGroupVersion.WithKind("Pod"),
+	"Deployment":  {Group: "apps", Version: "v1", Kind: "Deployment"},
+	"DaemonSet":   {Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	"StatefulSet": {Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	"ReplicaSet":  {Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+	"Job":         {Group: "batch", Version: "v1", Kind: "Job"},
+	"CronJob":     {Group: "batch", Version: "v1", Kind: "CronJob"},
+}
+
+func indexPolicyReportPolicies(obj client.Object) []string {
+	policyReport, ok := obj.(*policyreport.PolicyReport)
+	if !ok {
+		return nil
+	}
+	var policies []string
+	for _, result := range policyReport.Results {
+		if !resultIsPresent(result.Policy, policies) {
+			policies = append(policies, result.Policy)
+		}
+	}
+	return policies
+}
+
+func indexPolicyReportScopeUID(obj client.Object) []string {
+	policyReport, ok := obj.(*policyreport.PolicyReport)
+	if !ok || policyReport.Scope == nil || policyReport.Scope.UID == "" {
+		return nil
+	}
+	return []string{string(policyReport.Scope.UID)}
+}
+
+// policyReportRequests lists the cached PolicyReports matching the options as requests.
+func (r *PolicyReportReconciler) policyReportRequests(ctx context.Context, opts ...client.ListOption) []reconcile.Request {
+	var policyReports policyreport.PolicyReportList
+	if err := r.List(ctx, &policyReports, opts...); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list PolicyReports")
+		countFailure("PolicyReport", FailureFetch, err)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(policyReports.Items))
+	for _, policyReport := range policyReports.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&policyReport)})
+	}
+	return requests
+}
+
+// policyManifestRequests enqueues the PolicyReports with results for the Policy of a changed PolicyManifest.
+// The backoff of the Policy is reset, so that the PolicyReports waiting for its PolicyManifest are retried right away.
+func (r *PolicyReportReconciler) policyManifestRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	if r.ManifestBackoff != nil {
+		r.ManifestBackoff.Forget(obj.GetName())
+	}
+	return r.policyReportRequests(ctx, client.MatchingFields{PolicyReportPolicyIndex: obj.GetName()})
+}
+
+// automatedExceptionRequests enqueues the PolicyReport of the workload of an AutomatedException.
+func (r *PolicyReportReconciler) automatedExceptionRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.policyReportRequests(ctx,
+		client.InNamespace(obj.GetLabels()[utils.NamespaceLabelName]),
+		client.MatchingFields{PolicyReportScopeUIDIndex: obj.GetName()})
+}
+
+// namespaceRequests enqueues the PolicyReports of a Namespace.
+func (r *PolicyReportReconciler) namespaceRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.policyReportRequests(ctx, client.InNamespace(obj.GetName()))
+}
+
+// workloadRequests enqueues the PolicyReport of a deleted workload, which Kyverno names after the workload UID,
+// so that its AutomatedException is deleted along with it.
+func workloadRequests(_ context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: string(obj.GetUID())}}}
+}
+
+// managedPredicate selects the AutomatedExceptions managed by the recommender.
+var managedPredicate = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	return obj.GetLabels()[utils.AppLabelName] == utils.ComponentName
+})
+
+// deletedPredicate only selects deletions.
+var deletedPredicate = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	UpdateFunc:  func(event.UpdateEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// allowedProtectedPoliciesChanged selects the Namespaces whose allowed protected Policies changed.
+var allowedProtectedPoliciesChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectOld.GetAnnotations()[utils.AllowProtectedPoliciesAnnotation] != e.ObjectNew.GetAnnotations()[utils.AllowProtectedPoliciesAnnotation]
+	},
+}
+
+// notifyModeChange sends an event for the PolicyManifest if its mode changed, including its creation and deletion.
+func notifyModeChange(changes chan<- event.GenericEvent, name string, previousMode string, currentMode string) {
+	if changes == nil || previousMode == currentMode {
+		return
+	}
+	changes <- event.GenericEvent{Object: &policyAPI.PolicyManifest{ObjectMeta: metav1.ObjectMeta{Name: name}}}
+}
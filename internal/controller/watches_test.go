@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"slices"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// watchClient returns a fake client with the PolicyReport field indexes.
+func watchClient(objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(policyAPI.AddToScheme(scheme)).To(Succeed())
+	Expect(policyreport.AddToScheme(scheme)).To(Succeed())
+	Expect(recommenderAPI.AddToScheme(scheme)).To(Succeed())
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+		WithIndex(&policyreport.PolicyReport{}, PolicyReportPolicyIndex, indexPolicyReportPolicies).
+		WithIndex(&policyreport.PolicyReport{}, PolicyReportScopeUIDIndex, indexPolicyReportScopeUID).
+		Build()
+}
+
+// shard owns a fixed set of namespaces.
+type shard []string
+
+func (s shard) Owns(namespace string) bool {
+	return slices.Contains(s, namespace)
+}
+
+var _ = Describe("Watches", func() {
+	ctx := context.Background()
+
+	It("maps changes to the PolicyReports they affect", func() {
+		api := predicatePolicyReport("team-a", "api", "fail")
+		web := predicatePolicyReport("team-a", "web", "pass")
+		web.Results = web.Results[1:]
+		worker := predicatePolicyReport("team-b", "worker", "fail")
+		reconciler := predicateReconciler()
+		reconciler.Client = watchClient(api, web, worker)
+
+		key := func(policyReport *policyreport.PolicyReport) reconcile.Request {
+			return reconcile.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)}
+		}
+
+		Expect(reconciler.policyManifestRequests(ctx, &policyAPI.PolicyManifest{ObjectMeta: metav1.ObjectMeta{Name: "require-run-as-nonroot"}})).
+			To(ConsistOf(key(api), key(worker)))
+		Expect(reconciler.namespaceRequests(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}})).
+			To(ConsistOf(key(api), key(web)))
+		Expect(reconciler.automatedExceptionRequests(ctx, &policyAPI.AutomatedException{ObjectMeta: metav1.ObjectMeta{
+			Name:      "team-b-worker",
+			Namespace: "policy-exceptions",
+			Labels:    map[string]string{utils.NamespaceLabelName: "team-b"},
+		}})).To(ConsistOf(key(worker)))
+		Expect(workloadRequests(ctx, &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team-a", UID: "team-a-api"}})).
+			To(ConsistOf(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "team-a-api"}}))
+	})
+
+	It("notifies PolicyManifest mode changes once cached", func() {
+		changes := make(chan event.GenericEvent, 10)
+		policyManifest := &policyAPI.PolicyManifest{
+			ObjectMeta: metav1.ObjectMeta{Name: "require-run-as-nonroot"},
+			Spec:       policyAPI.PolicyManifestSpec{Mode: ManifestExpectedMode},
+		}
+		c := watchClient(policyManifest)
+		reconciler := &PolicyManifestReconciler{Client: c, PolicyManifestCache: NewPolicyManifestCache(), ModeChanges: changes}
+		request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "require-run-as-nonroot"}}
+
+		_, err := reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Receive(WithTransform(func(e event.GenericEvent) string { return e.Object.GetName() }, Equal("require-run-as-nonroot"))))
+
+		// Unchanged mode
+		_, err = reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).NotTo(Receive())
+
+		Expect(c.Get(ctx, request.NamespacedName, policyManifest)).To(Succeed())
+		policyManifest.Spec.Mode = "enforce"
+		Expect(c.Update(ctx, policyManifest)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Receive())
+		Expect(GetPolicyManifestMode("require-run-as-nonroot", reconciler.PolicyManifestCache)).To(Equal("enforce"))
+
+		// Deleted PolicyManifests are removed from the cache
+		Expect(c.Delete(ctx, policyManifest)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Receive())
+		Expect(reconciler.PolicyManifestCache.Len()).To(BeZero())
+	})
+
+	It("only reconciles the PolicyReports of its shard", func() {
+		policyReport := predicatePolicyReport("team-a", "api", "fail")
+		reconciler := predicateReconciler()
+		reconciler.Client = watchClient(policyReport)
+		request := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policyReport)}
+		key := types.NamespacedName{Namespace: "team-a", Name: "team-a-api"}
+		DeferCleanup(appliedAutomatedExceptions.Forget, key)
+
+		reconciler.Shard = shard{"team-b"}
+		_, err := reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(apierrors.IsNotFound(reconciler.Get(ctx, key, &policyAPI.AutomatedException{}))).To(BeTrue())
+
+		reconciler.Shard = shard{"team-a", "team-b"}
+		_, err = reconciler.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Get(ctx, key, &policyAPI.AutomatedException{})).To(Succeed())
+	})
+
+	Describe("deleting orphaned AutomatedExceptions", func() {
+		automatedException := func() *policyAPI.AutomatedException {
+			return &policyAPI.AutomatedException{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "team-a-api",
+					Namespace: "team-a",
+					Labels: map[string]string{
+						utils.AppLabelName:       utils.ComponentName,
+						utils.KindLabelName:      "Deployment",
+						utils.NamespaceLabelName: "team-a",
+						utils.NameLabelName:      "api",
+					},
+				},
+				Spec: policyAPI.AutomatedExceptionSpec{Policies: []string{"require-run-as-nonroot"}},
+			}
+		}
+		orphan := func(objects ...client.Object) bool {
+			reconciler := predicateReconciler()
+			reconciler.Client = watchClient(objects...)
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "team-a-api"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = reconciler.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "team-a-api"}, &policyAPI.AutomatedException{})
+			return apierrors.IsNotFound(err)
+		}
+
+		It("deletes the AutomatedException of a deleted workload", func() {
+			Expect(orphan(automatedException())).To(BeTrue())
+		})
+
+		It("keeps the AutomatedException while the workload exists", func() {
+			deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team-a", UID: "team-a-api"}}
+			Expect(orphan(automatedException(), deployment)).To(BeFalse())
+		})
+
+		It("keeps approved AutomatedExceptions", func() {
+			approved := automatedException()
+			approved.Annotations = map[string]string{utils.ApprovedByAnnotation: "alice"}
+			Expect(orphan(approved)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,104 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+const (
+	// ConfigMapKey holds the snapshots as JSON lines
+	ConfigMapKey = "snapshots.jsonl"
+	// maxConfigMapBytes keeps the ConfigMap below the 1MiB object size limit
+	maxConfigMapBytes = 900 * 1024
+)
+
+// ConfigMapStore keeps the last Capacity snapshots in a ConfigMap, dropping the oldest ones first.
+// The oldest snapshots are also dropped when the ConfigMap grows close to the object size limit.
+type ConfigMapStore struct {
+	Client   client.Client
+	Key      types.NamespacedName
+	Capacity int
+}
+
+func (s *ConfigMapStore) Append(ctx context.Context, snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	var configMap corev1.ConfigMap
+	err = s.Client.Get(ctx, s.Key, &configMap)
+	switch {
+	case apierrors.IsNotFound(err):
+		configMap = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.Key.Name,
+				Namespace: s.Key.Namespace,
+				Labels:    map[string]string{utils.AppLabelName: utils.ComponentName},
+			},
+			Data: map[string]string{ConfigMapKey: string(data) + "\n"},
+		}
+		return s.Client.Create(ctx, &configMap)
+	case err != nil:
+		return err
+	}
+
+	lines := append(splitLines(configMap.Data[ConfigMapKey]), string(data))
+	size := 0
+	for _, line := range lines {
+		size += len(line) + 1
+	}
+	// Drop the oldest snapshots, always keeping the new one
+	for len(lines) > 1 && ((s.Capacity > 0 && len(lines) > s.Capacity) || size > maxConfigMapBytes) {
+		size -= len(lines[0]) + 1
+		lines = lines[1:]
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[ConfigMapKey] = strings.Join(lines, "\n") + "\n"
+	// Conflicts are returned, the snapshot is retried by the Recorder
+	return s.Client.Update(ctx, &configMap)
+}
+
+func (s *ConfigMapStore) List(ctx context.Context, query Query) ([]Snapshot, error) {
+	var configMap corev1.ConfigMap
+	if err := s.Client.Get(ctx, s.Key, &configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return []Snapshot{}, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for i, line := range splitLines(configMap.Data[ConfigMapKey]) {
+		var snapshot Snapshot
+		if err := json.Unmarshal([]byte(line), &snapshot); err != nil {
+			return nil, fmt.Errorf("unable to decode snapshot %d of ConfigMap %s: %w", i, s.Key, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return query.Filter(snapshots), nil
+}
+
+func splitLines(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(data, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,43 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Handler serves the snapshots of the Store as JSON. The since, until, policy and namespace
+// query parameters map to the Query fields.
+type Handler struct {
+	Store Store
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	query := Query{
+		Since:     params.Get("since"),
+		Until:     params.Get("until"),
+		Policy:    params.Get("policy"),
+		Namespace: params.Get("namespace"),
+	}
+	for _, date := range []string{query.Since, query.Until} {
+		if _, err := time.Parse(DateFormat, date); date != "" && err != nil {
+			http.Error(w, fmt.Sprintf("invalid date %q, must be YYYY-MM-DD", date), http.StatusBadRequest)
+			return
+		}
+	}
+
+	snapshots, err := h.Store.List(req.Context(), query)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to list snapshots: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshots); err != nil {
+		log.FromContext(req.Context()).Error(err, "unable to write history")
+	}
+}
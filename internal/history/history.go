@@ -0,0 +1,130 @@
+package history
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// DateFormat is the layout of the snapshot dates, snapshots are taken once per UTC day
+const DateFormat = "2006-01-02"
+
+// Snapshot records the failing results and AutomatedExceptions of the cluster on a day.
+type Snapshot struct {
+	Date    string    `json:"date"`
+	TakenAt time.Time `json:"takenAt"`
+	// Totals over the buckets
+	FailingResults      int      `json:"failingResults"`
+	AutomatedExceptions int      `json:"automatedExceptions"`
+	Buckets             []Bucket `json:"buckets"`
+}
+
+// Bucket counts the failing results of a policy in a namespace and the AutomatedExceptions excepting it.
+type Bucket struct {
+	Policy              string `json:"policy"`
+	Namespace           string `json:"namespace"`
+	FailingResults      int    `json:"failingResults"`
+	AutomatedExceptions int    `json:"automatedExceptions"`
+}
+
+// Query selects snapshots and buckets, empty fields match everything.
+type Query struct {
+	// Since and Until are inclusive dates in DateFormat
+	Since     string
+	Until     string
+	Policy    string
+	Namespace string
+}
+
+// Store is an append-only history of snapshots. Backends which can't filter natively may use Query.Filter.
+type Store interface {
+	// Append records the snapshot after the existing ones
+	Append(ctx context.Context, snapshot Snapshot) error
+	// List returns the snapshots matching the query, oldest first
+	List(ctx context.Context, query Query) ([]Snapshot, error)
+}
+
+// Take builds the snapshot of the PolicyReports and AutomatedExceptions at the given time.
+// AutomatedExceptions are counted in the namespace of their workload.
+func Take(now time.Time, policyReports []policyreport.PolicyReport, automatedExceptions []policyAPI.AutomatedException) Snapshot {
+	buckets := make(map[Bucket]*Bucket)
+	bucket := func(policy string, namespace string) *Bucket {
+		key := Bucket{Policy: policy, Namespace: namespace}
+		if buckets[key] == nil {
+			buckets[key] = &Bucket{Policy: policy, Namespace: namespace}
+		}
+		return buckets[key]
+	}
+
+	for _, policyReport := range policyReports {
+		for _, result := range policyReport.Results {
+			if result.Result == "fail" {
+				bucket(result.Policy, policyReport.Namespace).FailingResults++
+			}
+		}
+	}
+	for _, automatedException := range automatedExceptions {
+		namespace := automatedException.Labels[utils.NamespaceLabelName]
+		if namespace == "" {
+			namespace = automatedException.Namespace
+		}
+		for _, policy := range automatedException.Spec.Policies {
+			bucket(policy, namespace).AutomatedExceptions++
+		}
+	}
+
+	snapshot := Snapshot{
+		Date:    now.UTC().Format(DateFormat),
+		TakenAt: now.UTC(),
+		Buckets: make([]Bucket, 0, len(buckets)),
+	}
+	for _, b := range buckets {
+		snapshot.Buckets = append(snapshot.Buckets, *b)
+	}
+	sort.Slice(snapshot.Buckets, func(i, j int) bool {
+		if snapshot.Buckets[i].Policy != snapshot.Buckets[j].Policy {
+			return snapshot.Buckets[i].Policy < snapshot.Buckets[j].Policy
+		}
+		return snapshot.Buckets[i].Namespace < snapshot.Buckets[j].Namespace
+	})
+	snapshot.total()
+
+	return snapshot
+}
+
+// total recomputes the totals from the buckets
+func (s *Snapshot) total() {
+	s.FailingResults, s.AutomatedExceptions = 0, 0
+	for _, bucket := range s.Buckets {
+		s.FailingResults += bucket.FailingResults
+		s.AutomatedExceptions += bucket.AutomatedExceptions
+	}
+}
+
+// Filter returns the snapshots in the date range restricted to the matching buckets, with their totals recomputed.
+func (q Query) Filter(snapshots []Snapshot) []Snapshot {
+	filtered := []Snapshot{}
+	for _, snapshot := range snapshots {
+		if (q.Since != "" && snapshot.Date < q.Since) || (q.Until != "" && snapshot.Date > q.Until) {
+			continue
+		}
+		if q.Policy != "" || q.Namespace != "" {
+			buckets := []Bucket{}
+			for _, bucket := range snapshot.Buckets {
+				if (q.Policy == "" || bucket.Policy == q.Policy) && (q.Namespace == "" || bucket.Namespace == q.Namespace) {
+					buckets = append(buckets, bucket)
+				}
+			}
+			snapshot.Buckets = buckets
+			snapshot.total()
+		}
+		filtered = append(filtered, snapshot)
+	}
+	return filtered
+}
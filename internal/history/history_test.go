@@ -0,0 +1,145 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+var _ = Describe("History", func() {
+	ctx := context.Background()
+	day := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	failing := func(namespace string, name string, policies ...string) *policyreport.PolicyReport {
+		policyReport := &policyreport.PolicyReport{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		for _, policy := range policies {
+			policyReport.Results = append(policyReport.Results, policyreport.PolicyReportResult{Policy: policy, Result: "fail"})
+		}
+		return policyReport
+	}
+	excepted := func(namespace string, name string, policies ...string) *policyAPI.AutomatedException {
+		return &policyAPI.AutomatedException{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "policy-exceptions",
+				Labels:    map[string]string{utils.NamespaceLabelName: namespace},
+			},
+			Spec: policyAPI.AutomatedExceptionSpec{Policies: policies},
+		}
+	}
+	snapshot := func(date string, failingResults int) Snapshot {
+		return Snapshot{Date: date, FailingResults: failingResults, Buckets: []Bucket{{Policy: "require-labels", Namespace: "team-a", FailingResults: failingResults}}}
+	}
+
+	It("takes snapshots per policy and namespace", func() {
+		taken := Take(day,
+			[]policyreport.PolicyReport{*failing("team-a", "api", "require-labels", "require-run-as-nonroot"), *failing("team-a", "web", "require-labels"), *failing("team-b", "api", "require-labels")},
+			[]policyAPI.AutomatedException{*excepted("team-a", "api", "require-labels", "require-run-as-nonroot")})
+
+		Expect(taken.Date).To(Equal("2026-03-01"))
+		Expect(taken.FailingResults).To(Equal(4))
+		Expect(taken.AutomatedExceptions).To(Equal(2))
+		Expect(taken.Buckets).To(Equal([]Bucket{
+			{Policy: "require-labels", Namespace: "team-a", FailingResults: 2, AutomatedExceptions: 1},
+			{Policy: "require-labels", Namespace: "team-b", FailingResults: 1},
+			{Policy: "require-run-as-nonroot", Namespace: "team-a", FailingResults: 1, AutomatedExceptions: 1},
+		}))
+	})
+
+	It("filters snapshots by date, policy and namespace", func() {
+		taken := Take(day, []policyreport.PolicyReport{*failing("team-a", "api", "require-labels"), *failing("team-b", "api", "require-labels")}, nil)
+		snapshots := []Snapshot{snapshot("2026-02-28", 5), taken}
+
+		filtered := Query{Since: "2026-03-01", Namespace: "team-b"}.Filter(snapshots)
+
+		Expect(filtered).To(HaveLen(1))
+		Expect(filtered[0].Buckets).To(ConsistOf(HaveField("Namespace", "team-b")))
+		Expect(filtered[0].FailingResults).To(Equal(1))
+	})
+
+	It("appends to a file", func() {
+		store := &FileStore{Path: filepath.Join(GinkgoT().TempDir(), "history.jsonl")}
+
+		Expect(store.List(ctx, Query{})).To(BeEmpty())
+		Expect(store.Append(ctx, snapshot("2026-02-28", 5))).To(Succeed())
+		Expect(store.Append(ctx, snapshot("2026-03-01", 3))).To(Succeed())
+
+		Expect(store.List(ctx, Query{})).To(HaveExactElements(HaveField("Date", "2026-02-28"), HaveField("Date", "2026-03-01")))
+	})
+
+	It("keeps the last snapshots in a ConfigMap", func() {
+		store := &ConfigMapStore{
+			Client:   fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build(),
+			Key:      types.NamespacedName{Namespace: "policy-system", Name: "history"},
+			Capacity: 2,
+		}
+
+		for i := 1; i <= 3; i++ {
+			Expect(store.Append(ctx, snapshot(fmt.Sprintf("2026-03-0%d", i), i))).To(Succeed())
+		}
+
+		Expect(store.List(ctx, Query{})).To(HaveExactElements(HaveField("Date", "2026-03-02"), HaveField("Date", "2026-03-03")))
+	})
+
+	Describe("the Recorder", func() {
+		var store *FileStore
+		var recorder *Recorder
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(policyAPI.AddToScheme(scheme)).To(Succeed())
+			Expect(policyreport.AddToScheme(scheme)).To(Succeed())
+
+			store = &FileStore{Path: filepath.Join(GinkgoT().TempDir(), "history.jsonl")}
+			recorder = &Recorder{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects([]client.Object{failing("team-a", "api", "require-labels")}...).Build(),
+				Store:  store,
+				Log:    logr.Discard(),
+			}
+		})
+
+		It("records a single snapshot per day", func() {
+			Expect(recorder.Record(ctx, day)).To(BeTrue())
+			Expect(recorder.Record(ctx, day.Add(time.Hour))).To(BeFalse())
+			Expect(recorder.Record(ctx, day.Add(24*time.Hour))).To(BeTrue())
+
+			Expect(store.List(ctx, Query{})).To(HaveExactElements(HaveField("Date", "2026-03-01"), HaveField("Date", "2026-03-02")))
+		})
+
+		It("serves the snapshots", func() {
+			Expect(recorder.Record(ctx, day)).To(BeTrue())
+
+			response := httptest.NewRecorder()
+			(&Handler{Store: store}).ServeHTTP(response, httptest.NewRequest("GET", "/history?since=2026-03-01&policy=require-labels", nil))
+
+			var snapshots []Snapshot
+			Expect(json.Unmarshal(response.Body.Bytes(), &snapshots)).To(Succeed())
+			Expect(snapshots).To(ConsistOf(HaveField("FailingResults", 1)))
+		})
+
+		It("rejects invalid dates", func() {
+			response := httptest.NewRecorder()
+			(&Handler{Store: store}).ServeHTTP(response, httptest.NewRequest("GET", "/history?since=yesterday", nil))
+
+			Expect(response.Code).To(Equal(400))
+		})
+	})
+})
@@ -0,0 +1,80 @@
+package history
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+// DefaultInterval is how often the Recorder checks whether the snapshot of the day was taken
+const DefaultInterval = time.Hour
+
+// Recorder appends a snapshot to the Store once per UTC day. It runs on the leader only.
+type Recorder struct {
+	Client   client.Reader
+	Store    Store
+	Log      logr.Logger
+	Interval time.Duration
+}
+
+// Start records the snapshot of the day on start and then checks every Interval until the context is done.
+func (r *Recorder) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Record(ctx, time.Now()); err != nil {
+			// Retried on the next tick
+			r.Log.Error(err, "unable to record history snapshot")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection makes sure a single replica appends to the Store.
+func (r *Recorder) NeedLeaderElection() bool {
+	return true
+}
+
+// Record appends the snapshot of the day of now unless the Store already has it, and tells whether it did.
+func (r *Recorder) Record(ctx context.Context, now time.Time) (bool, error) {
+	date := now.UTC().Format(DateFormat)
+	existing, err := r.Store.List(ctx, Query{Since: date, Until: date})
+	if err != nil {
+		return false, err
+	}
+	if len(existing) > 0 {
+		return false, nil
+	}
+
+	var policyReports policyreport.PolicyReportList
+	if err := r.Client.List(ctx, &policyReports); err != nil {
+		return false, err
+	}
+	var automatedExceptions policyAPI.AutomatedExceptionList
+	if err := r.Client.List(ctx, &automatedExceptions); err != nil {
+		return false, err
+	}
+
+	snapshot := Take(now, policyReports.Items, automatedExceptions.Items)
+	if err := r.Store.Append(ctx, snapshot); err != nil {
+		return false, err
+	}
+	r.Log.Info("Recorded history snapshot", "date", snapshot.Date, "failingResults", snapshot.FailingResults, "automatedExceptions", snapshot.AutomatedExceptions)
+
+	return true, nil
+}
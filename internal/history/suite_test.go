@@ -0,0 +1,14 @@
+package history
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHistory(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "History Suite")
+}
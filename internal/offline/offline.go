@@ -0,0 +1,310 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	policyreport "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	"github.com/giantswarm/exception-recommender/internal/controller"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+const (
+	OutputYAML = "yaml"
+	OutputJSON = "json"
+)
+
+// Options configures the offline recommendations, mirroring the manager flags.
+type Options struct {
+	ReportsDir           string
+	ManifestsDir         string
+	Output               string
+	DestinationNamespace string
+	TargetWorkloads      []string
+	TargetCategories     []string
+	ExcludeNamespaces    []string
+	ProtectedPolicies    []string
+
+	// Workload selection of the explain subcommand
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// Run parses the offline subcommand arguments, computes the AutomatedExceptions
+// from the PolicyReport and PolicyManifest files and prints them to out.
+func Run(args []string, out io.Writer) error {
+	options, err := ParseFlags("offline", args)
+	if err != nil {
+		return err
+	}
+
+	automatedExceptions, err := Recommend(options)
+	if err != nil {
+		return err
+	}
+
+	return Print(out, automatedExceptions, options.Output)
+}
+
+// Explain parses the explain subcommand arguments and prints the trace of every decision taken
+// for the workload from the PolicyReport and PolicyManifest files to out.
+func Explain(args []string, out io.Writer) error {
+	options, err := ParseFlags("explain", args)
+	if err != nil {
+		return err
+	}
+
+	reconciler, inputs, err := Reconciler(options)
+	if err != nil {
+		return err
+	}
+
+	explanation := reconciler.Explain(context.Background(), inputs.PolicyReports, inputs.AllowedProtectedPolicies(options.Namespace), options.Namespace, options.Kind, options.Name)
+	return write(out, explanation, options.Output)
+}
+
+// ParseFlags parses the arguments shared by the offline subcommands.
+func ParseFlags(name string, args []string) (Options, error) {
+	var options Options
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&options.ReportsDir, "reports", "", "Directory containing PolicyReport YAML or JSON files.")
+	fs.StringVar(&options.ManifestsDir, "manifests", "", "Directory containing PolicyManifest YAML or JSON files.")
+	fs.StringVar(&options.Output, "output", OutputYAML, "Output format: 'yaml' or 'json'.")
+	fs.StringVar(&options.DestinationNamespace, "destination-namespace", "", "The namespace where the AutomatedExceptions would be created. Defaults to resource namespace.")
+	fs.Func("target-categories", "A comma-separated list of Kyverno Policy Categories to be included in the Draft generation.", appendItems(&options.TargetCategories))
+	fs.Func("target-workloads", "A comma-separated list of workloads to be included in the Draft generation.", appendItems(&options.TargetWorkloads))
+	fs.Func("exclude-namespaces", "A comma-separated list of namespaces to be excluded from draft generation.", appendItems(&options.ExcludeNamespaces))
+	fs.Func("protected-policies", "A comma-separated list of Kyverno Policies which are never automatically excepted. "+
+		"A Namespace found in the input files may allow some of them with the "+utils.AllowProtectedPoliciesAnnotation+" annotation.", appendItems(&options.ProtectedPolicies))
+
+	if name == "explain" {
+		fs.StringVar(&options.Namespace, "namespace", "", "Namespace of the workload to explain.")
+		fs.StringVar(&options.Kind, "kind", "", "Kind of the workload to explain.")
+		fs.StringVar(&options.Name, "name", "", "Name of the workload to explain.")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return options, err
+	}
+
+	if name == "explain" && (options.Namespace == "" || options.Kind == "" || options.Name == "") {
+		return options, fmt.Errorf("--namespace, --kind and --name are required")
+	}
+
+	if options.ReportsDir == "" {
+		return options, fmt.Errorf("--reports is required")
+	}
+	if options.Output != OutputYAML && options.Output != OutputJSON {
+		return options, fmt.Errorf("invalid --output %q, must be 'yaml' or 'json'", options.Output)
+	}
+
+	return options, nil
+}
+
+func appendItems(items *[]string) func(string) error {
+	return func(input string) error {
+		*items = append(*items, strings.Split(input, ",")...)
+		return nil
+	}
+}
+
+// Inputs are the objects found in the reports and manifests directories.
+type Inputs struct {
+	PolicyReports   []policyreport.PolicyReport
+	PolicyManifests []policyAPI.PolicyManifest
+	Namespaces      []corev1.Namespace
+}
+
+// AllowedProtectedPolicies returns the protected Policies allowed by the Namespace, if it was found.
+func (i Inputs) AllowedProtectedPolicies(namespace string) []string {
+	for _, ns := range i.Namespaces {
+		if ns.Name == namespace {
+			return utils.AllowedProtectedPolicies(ns.Annotations)
+		}
+	}
+	return nil
+}
+
+// Reconciler returns a PolicyReportReconciler without client, holding the PolicyManifests found in the
+// manifests directory, along with the PolicyReports and Namespaces found in both directories.
+func Reconciler(options Options) (*controller.PolicyReportReconciler, Inputs, error) {
+	var inputs Inputs
+
+	for _, dir := range []string{options.ReportsDir, options.ManifestsDir} {
+		if dir == "" {
+			continue
+		}
+		if err := load(dir, &inputs); err != nil {
+			return nil, inputs, err
+		}
+	}
+
+	return &controller.PolicyReportReconciler{
+		DestinationNamespace: options.DestinationNamespace,
+		TargetWorkloads:      options.TargetWorkloads,
+		TargetCategories:     options.TargetCategories,
+		ExcludeNamespaces:    options.ExcludeNamespaces,
+		ProtectedPolicies:    options.ProtectedPolicies,
+		PolicyManifestCache:  controller.NewPolicyManifestCache(inputs.PolicyManifests...),
+	}, inputs, nil
+}
+
+// Recommend computes the AutomatedExceptions the recommender would create, sorted by namespace and name.
+func Recommend(options Options) ([]policyAPI.AutomatedException, error) {
+	reconciler, inputs, err := Reconciler(options)
+	if err != nil {
+		return nil, err
+	}
+
+	automatedExceptions := []policyAPI.AutomatedException{}
+	for _, policyReport := range inputs.PolicyReports {
+		var allowedProtectedPolicies []string
+		if policyReport.Scope != nil {
+			allowedProtectedPolicies = inputs.AllowedProtectedPolicies(policyReport.Scope.Namespace)
+		}
+		recommendation := reconciler.Recommend(context.Background(), policyReport, allowedProtectedPolicies)
+		if recommendation.Skipped || len(recommendation.FailedPolicies) == 0 {
+			continue
+		}
+
+		automatedExceptions = append(automatedExceptions, utils.TemplateAutomatedException(policyReport, recommendation.FailedPolicies, recommendation.Namespace))
+	}
+
+	sort.Slice(automatedExceptions, func(i, j int) bool {
+		if automatedExceptions[i].Namespace != automatedExceptions[j].Namespace {
+			return automatedExceptions[i].Namespace < automatedExceptions[j].Namespace
+		}
+		return automatedExceptions[i].Name < automatedExceptions[j].Name
+	})
+
+	return automatedExceptions, nil
+}
+
+// Print writes the AutomatedExceptions to out as a List in the given format.
+func Print(out io.Writer, automatedExceptions []policyAPI.AutomatedException, output string) error {
+	return write(out, policyAPI.AutomatedExceptionList{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"},
+		Items:    automatedExceptions,
+	}, output)
+}
+
+func write(out io.Writer, object interface{}, output string) error {
+	var data []byte
+	var err error
+	switch output {
+	case OutputJSON:
+		data, err = json.MarshalIndent(object, "", "  ")
+		data = append(data, '\n')
+	default:
+		data, err = yaml.Marshal(object)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(data)
+	return err
+}
+
+// load decodes every YAML or JSON file in dir, keeping PolicyReports, PolicyManifests and Namespaces.
+// Files may contain multiple documents and Lists.
+func load(dir string, inputs *Inputs) error {
+	return filepath.WalkDir(dir, func(path string, _ os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		file, err := os.Open(path) // nolint:gosec // path comes from the user provided directory.
+		if err != nil {
+			return err
+		}
+		defer file.Close() // nolint:errcheck
+
+		decoder := utilyaml.NewYAMLOrJSONDecoder(file, 4096)
+		for {
+			var document json.RawMessage
+			if err := decoder.Decode(&document); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return fmt.Errorf("unable to decode %s: %w", path, err)
+			}
+			if err := collect(document, "", inputs); err != nil {
+				return fmt.Errorf("unable to decode %s: %w", path, err)
+			}
+		}
+	})
+}
+
+// collect decodes the PolicyReports, PolicyManifests and Namespaces out of a JSON document.
+// defaultKind is used for items of typed Lists, which omit their kind.
+func collect(document json.RawMessage, defaultKind string, inputs *Inputs) error {
+	if len(document) == 0 || string(document) == "null" {
+		return nil
+	}
+
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(document, &typeMeta); err != nil {
+		return err
+	}
+	kind := typeMeta.Kind
+	if kind == "" {
+		kind = defaultKind
+	}
+
+	switch {
+	case kind == "PolicyReport":
+		var policyReport policyreport.PolicyReport
+		if err := json.Unmarshal(document, &policyReport); err != nil {
+			return err
+		}
+		inputs.PolicyReports = append(inputs.PolicyReports, policyReport)
+	case kind == "PolicyManifest":
+		var policyManifest policyAPI.PolicyManifest
+		if err := json.Unmarshal(document, &policyManifest); err != nil {
+			return err
+		}
+		inputs.PolicyManifests = append(inputs.PolicyManifests, policyManifest)
+	case kind == "Namespace":
+		var namespace corev1.Namespace
+		if err := json.Unmarshal(document, &namespace); err != nil {
+			return err
+		}
+		inputs.Namespaces = append(inputs.Namespaces, namespace)
+	case strings.HasSuffix(kind, "List"):
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(document, &list); err != nil {
+			return err
+		}
+		for _, item := range list.Items {
+			if err := collect(item, strings.TrimSuffix(kind, "List"), inputs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
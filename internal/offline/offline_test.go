@@ -0,0 +1,109 @@
+package offline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/giantswarm/exception-recommender/internal/controller"
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// Regenerate the golden files with: go test ./internal/offline/... -update
+var update = flag.Bool("update", false, "update golden files")
+
+var _ = Describe("Offline recommendations", func() {
+	args := []string{
+		"--reports", filepath.Join("testdata", "reports"),
+		"--manifests", filepath.Join("testdata", "manifests"),
+		"--target-workloads", "Deployment,DaemonSet,StatefulSet",
+		"--target-categories", "Pod Security Standards (Restricted)",
+		"--exclude-namespaces", "kube-system",
+		"--destination-namespace", "policy-exceptions",
+	}
+
+	It("must match the golden file", func() {
+		var out bytes.Buffer
+		Expect(Run(args, &out)).To(Succeed())
+
+		golden := filepath.Join("testdata", "golden.yaml")
+		if *update {
+			Expect(os.WriteFile(golden, out.Bytes(), 0o600)).To(Succeed())
+		}
+
+		expected, err := os.ReadFile(golden)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(Equal(string(expected)))
+	})
+
+	It("must only draft policies in warming mode", func() {
+		options, err := ParseFlags("offline", args)
+		Expect(err).NotTo(HaveOccurred())
+
+		automatedExceptions, err := Recommend(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(automatedExceptions).To(HaveLen(2))
+		Expect(automatedExceptions[1].Spec.Policies).To(ConsistOf("require-run-as-nonroot", "disallow-privilege-escalation"))
+	})
+
+	It("must explain why a workload got no exception", func() {
+		options, err := ParseFlags("explain", append(args, "--namespace", "kube-system", "--kind", "DaemonSet", "--name", "kube-proxy"))
+		Expect(err).NotTo(HaveOccurred())
+
+		reconciler, inputs, err := Reconciler(options)
+		Expect(err).NotTo(HaveOccurred())
+
+		explanation := reconciler.Explain(context.Background(), inputs.PolicyReports, nil, options.Namespace, options.Kind, options.Name)
+		Expect(explanation.AutomatedException).To(BeNil())
+		Expect(explanation.Decisions).To(ContainElement(HaveField("Step", controller.StepNamespace)))
+		Expect(explanation.Decisions[0].Outcome).To(Equal(controller.OutcomeSkipped))
+	})
+
+	It("must explain why a workload got an exception", func() {
+		var out bytes.Buffer
+		Expect(Explain(append(args, "--namespace", "default", "--kind", "Deployment", "--name", "app-deployment", "--output", "json"), &out)).To(Succeed())
+
+		var explanation controller.Explanation
+		Expect(json.Unmarshal(out.Bytes(), &explanation)).To(Succeed())
+		Expect(explanation.AutomatedException).NotTo(BeNil())
+		Expect(explanation.Decisions).To(ContainElement(And(
+			HaveField("Policy", "restrict-seccomp-strict"),
+			HaveField("Outcome", controller.OutcomeSkipped),
+		)))
+	})
+
+	It("must skip the protected policies not allowed by the namespace", func() {
+		protectedArgs := append(append([]string{}, args...), "--protected-policies", "require-run-as-nonroot,disallow-privilege-escalation")
+		options, err := ParseFlags("offline", protectedArgs)
+		Expect(err).NotTo(HaveOccurred())
+
+		automatedExceptions, err := Recommend(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(automatedExceptions).To(ContainElement(And(
+			HaveField("Labels", HaveKeyWithValue(utils.NameLabelName, "app-deployment")),
+			HaveField("Spec.Policies", ConsistOf("disallow-privilege-escalation")),
+		)))
+
+		var out bytes.Buffer
+		Expect(Explain(append(protectedArgs, "--namespace", "default", "--kind", "Deployment", "--name", "app-deployment", "--output", "json"), &out)).To(Succeed())
+
+		var explanation controller.Explanation
+		Expect(json.Unmarshal(out.Bytes(), &explanation)).To(Succeed())
+		Expect(explanation.Decisions).To(ContainElement(And(
+			HaveField("Step", controller.StepProtected),
+			HaveField("Policy", "require-run-as-nonroot"),
+		)))
+		Expect(explanation.AutomatedException.Spec.Policies).To(ConsistOf("disallow-privilege-escalation"))
+	})
+
+	It("must require the reports directory", func() {
+		_, err := ParseFlags("offline", []string{"--output", "json"})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,14 @@
+package offline
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOffline(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Offline Suite")
+}
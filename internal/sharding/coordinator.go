@@ -0,0 +1,239 @@
+package sharding
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// GroupLabel selects the Leases of the replicas sharing the namespaces
+	GroupLabel = "policy.giantswarm.io/shard-group"
+	// DefaultLeaseDuration is how long a replica keeps its namespaces without renewing its Lease
+	DefaultLeaseDuration = 30 * time.Second
+)
+
+// Coordinator shards the namespaces across the replicas holding a live Lease of the Group. Every replica renews
+// its own Lease and builds the same Ring from the live Leases, so that each namespace is owned by a single replica
+// once the replicas observed each other. Namespaces are rebalanced when a replica joins, or leaves or stops
+// renewing its Lease.
+type Coordinator struct {
+	// Client writes the Lease of the replica and lists the Namespaces
+	Client client.Client
+	// LeaseReader reads the Leases, from the API server as only the Leases of their namespace may be readable
+	LeaseReader client.Reader
+	// Namespace and Group of the Leases, their names are prefixed with the Group
+	Namespace string
+	Group     string
+	// Identity of the replica, unique across the replicas
+	Identity      string
+	LeaseDuration time.Duration
+	// Changes receives the Namespaces newly owned by the replica, when set
+	Changes chan<- event.GenericEvent
+	Log     logr.Logger
+
+	mu    sync.RWMutex
+	ring  *Ring
+	owned map[string]bool
+	// renewedAt is the last time the Lease of the replica was renewed
+	renewedAt time.Time
+}
+
+// Owns returns whether the replica reconciles the namespace. Nothing is owned until the replica joined the Ring.
+func (c *Coordinator) Owns(namespace string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ring != nil && c.ring.Owner(namespace) == c.Identity
+}
+
+// Members returns the number of replicas sharing the namespaces, 1 until the replica joined the Ring.
+func (c *Coordinator) Members() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.ring == nil {
+		return 1
+	}
+	return len(c.ring.Members())
+}
+
+// NeedLeaderElection runs the Coordinator on every replica.
+func (c *Coordinator) NeedLeaderElection() bool {
+	return false
+}
+
+// Start syncs the shards until the context is done, then releases the Lease of the replica so that its
+// namespaces are rebalanced right away.
+func (c *Coordinator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.leaseDuration() / 3)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Sync(ctx, time.Now()); err != nil {
+			c.Log.Error(err, "unable to sync shards")
+		}
+
+		select {
+		case <-ctx.Done():
+			return c.release()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync renews the Lease of the replica, rebuilds the Ring from the live Leases and enqueues the namespaces
+// newly owned by the replica. The replica owns nothing once its Lease wasn't renewed for the LeaseDuration,
+// since the other replicas took its namespaces over.
+func (c *Coordinator) Sync(ctx context.Context, now time.Time) error {
+	if err := c.renew(ctx, now); err != nil {
+		c.mu.Lock()
+		expired := c.ring != nil && !now.Before(c.renewedAt.Add(c.leaseDuration()))
+		if expired {
+			c.ring = nil
+			c.owned = nil
+		}
+		c.mu.Unlock()
+		if expired {
+			c.Log.Info("Shard Lease expired, releasing the namespaces until it is renewed")
+			OwnedNamespacesMetric.WithLabelValues(c.Identity).Set(0)
+		}
+		return err
+	}
+	c.mu.Lock()
+	c.renewedAt = now
+	c.mu.Unlock()
+
+	var leases coordinationv1.LeaseList
+	if err := c.LeaseReader.List(ctx, &leases, client.InNamespace(c.Namespace), client.MatchingLabels{GroupLabel: c.Group}); err != nil {
+		return err
+	}
+	// The replica is a member as soon as its Lease is renewed
+	members := []string{c.Identity}
+	for _, lease := range leases.Items {
+		if live(lease, now) {
+			members = append(members, *lease.Spec.HolderIdentity)
+		}
+	}
+	ring := NewRing(members, DefaultVirtualNodes)
+
+	var namespaces corev1.NamespaceList
+	if err := c.Client.List(ctx, &namespaces); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	rebalanced := c.ring != nil && !slices.Equal(c.ring.Members(), ring.Members())
+	joined := c.ring == nil
+	c.ring = ring
+	owned := make(map[string]bool)
+	var gained []string
+	for _, namespace := range namespaces.Items {
+		if ring.Owner(namespace.Name) != c.Identity {
+			continue
+		}
+		owned[namespace.Name] = true
+		if !c.owned[namespace.Name] {
+			gained = append(gained, namespace.Name)
+		}
+	}
+	c.owned = owned
+	c.mu.Unlock()
+
+	if joined || rebalanced {
+		c.Log.Info("Sharding namespaces", "members", ring.Members(), "ownedNamespaces", len(owned))
+	}
+	if rebalanced {
+		RebalancesMetric.Inc()
+	}
+	MembersMetric.Set(float64(len(ring.Members())))
+	OwnedNamespacesMetric.WithLabelValues(c.Identity).Set(float64(len(owned)))
+
+	// Lease renewals mustn't wait for the controller to receive the Namespaces
+	if c.Changes != nil && len(gained) != 0 {
+		go c.enqueue(ctx, gained)
+	}
+
+	return nil
+}
+
+// enqueue sends the Namespaces to the Changes channel, until the context is done.
+func (c *Coordinator) enqueue(ctx context.Context, namespaces []string) {
+	for _, namespace := range namespaces {
+		select {
+		case c.Changes <- event.GenericEvent{Object: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// renew creates or renews the Lease of the replica.
+func (c *Coordinator) renew(ctx context.Context, now time.Time) error {
+	var lease coordinationv1.Lease
+	err := c.LeaseReader.Get(ctx, client.ObjectKey{Namespace: c.Namespace, Name: c.leaseName()}, &lease)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	lease.Spec = coordinationv1.LeaseSpec{
+		HolderIdentity:       ptr.To(c.Identity),
+		LeaseDurationSeconds: ptr.To(int32(c.leaseDuration().Seconds())),
+		AcquireTime:          lease.Spec.AcquireTime,
+		RenewTime:            &metav1.MicroTime{Time: now},
+	}
+	if errors.IsNotFound(err) {
+		lease.ObjectMeta = metav1.ObjectMeta{
+			Name:      c.leaseName(),
+			Namespace: c.Namespace,
+			Labels:    map[string]string{GroupLabel: c.Group},
+		}
+		lease.Spec.AcquireTime = &metav1.MicroTime{Time: now}
+		return c.Client.Create(ctx, &lease)
+	}
+	return c.Client.Update(ctx, &lease)
+}
+
+// release deletes the Lease of the replica.
+func (c *Coordinator) release() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: c.leaseName(), Namespace: c.Namespace}}
+	if err := c.Client.Delete(ctx, lease); client.IgnoreNotFound(err) != nil {
+		c.Log.Error(err, "unable to release shard Lease")
+		return err
+	}
+	OwnedNamespacesMetric.DeleteLabelValues(c.Identity)
+	return nil
+}
+
+func (c *Coordinator) leaseName() string {
+	return c.Group + "-" + c.Identity
+}
+
+func (c *Coordinator) leaseDuration() time.Duration {
+	if c.LeaseDuration <= 0 {
+		return DefaultLeaseDuration
+	}
+	return c.LeaseDuration
+}
+
+// live returns whether the Lease was renewed within its duration.
+func live(lease coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.HolderIdentity == nil || lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return false
+	}
+	expiresAt := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return expiresAt.After(now)
+}
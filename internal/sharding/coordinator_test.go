@@ -0,0 +1,154 @@
+package sharding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Coordinator", func() {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var (
+		k8sClient client.Client
+		changes   map[string]chan event.GenericEvent
+	)
+
+	coordinator := func(identity string) *Coordinator {
+		changes[identity] = make(chan event.GenericEvent, 100)
+		return &Coordinator{
+			Client:        k8sClient,
+			LeaseReader:   k8sClient,
+			Namespace:     "recommender",
+			Group:         "exception-recommender",
+			Identity:      identity,
+			LeaseDuration: 30 * time.Second,
+			Changes:       changes[identity],
+			Log:           logr.Discard(),
+		}
+	}
+	owned := func(coordinators ...*Coordinator) map[string][]string {
+		owned := make(map[string][]string)
+		for i := range 30 {
+			namespace := fmt.Sprintf("namespace-%d", i)
+			for _, c := range coordinators {
+				if c.Owns(namespace) {
+					owned[namespace] = append(owned[namespace], c.Identity)
+				}
+			}
+		}
+		return owned
+	}
+	received := func(identity string) []string {
+		var first event.GenericEvent
+		Eventually(changes[identity]).Should(Receive(&first))
+		namespaces := []string{first.Object.GetName()}
+		for {
+			select {
+			case e := <-changes[identity]:
+				namespaces = append(namespaces, e.Object.GetName())
+			case <-time.After(100 * time.Millisecond):
+				return namespaces
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		objects := []client.Object{}
+		for i := range 30 {
+			objects = append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("namespace-%d", i)}})
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).Build()
+		changes = make(map[string]chan event.GenericEvent)
+	})
+
+	It("owns nothing before joining", func() {
+		Expect(coordinator("a").Owns("namespace-0")).To(BeFalse())
+	})
+
+	It("partitions the namespaces across the replicas", func() {
+		a, b := coordinator("a"), coordinator("b")
+		Expect(a.Sync(ctx, now)).To(Succeed())
+		Expect(owned(a)).To(HaveLen(30))
+		Expect(received("a")).To(HaveLen(30))
+
+		Expect(b.Sync(ctx, now)).To(Succeed())
+		Expect(a.Sync(ctx, now)).To(Succeed())
+		partition := owned(a, b)
+		Expect(partition).To(HaveLen(30))
+		for _, owners := range partition {
+			Expect(owners).To(HaveLen(1))
+		}
+		Expect(testutil.ToFloat64(MembersMetric)).To(Equal(2.0))
+		Expect(testutil.ToFloat64(OwnedNamespacesMetric.WithLabelValues("a")) +
+			testutil.ToFloat64(OwnedNamespacesMetric.WithLabelValues("b"))).To(Equal(30.0))
+
+		var lease coordinationv1.Lease
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "recommender", Name: "exception-recommender-b"}, &lease)).To(Succeed())
+		Expect(lease.Labels).To(HaveKeyWithValue(GroupLabel, "exception-recommender"))
+		Expect(*lease.Spec.HolderIdentity).To(Equal("b"))
+	})
+
+	It("hands the namespaces of expired Leases over", func() {
+		a, b := coordinator("a"), coordinator("b")
+		Expect(a.Sync(ctx, now)).To(Succeed())
+		Expect(b.Sync(ctx, now)).To(Succeed())
+		Expect(a.Sync(ctx, now)).To(Succeed())
+		received("a")
+		ownedByA := len(owned(a))
+		Expect(ownedByA).To(BeNumerically("<", 30))
+
+		rebalances := testutil.ToFloat64(RebalancesMetric)
+		Expect(a.Sync(ctx, now.Add(time.Minute))).To(Succeed())
+		Expect(owned(a)).To(HaveLen(30))
+		Expect(received("a")).To(HaveLen(30 - ownedByA))
+		Expect(testutil.ToFloat64(RebalancesMetric)).To(Equal(rebalances + 1))
+	})
+
+	It("owns nothing once it failed to renew its Lease for the lease duration", func() {
+		a, b := coordinator("a"), coordinator("b")
+		Expect(a.Sync(ctx, now)).To(Succeed())
+		Expect(b.Sync(ctx, now)).To(Succeed())
+		Expect(a.Sync(ctx, now)).To(Succeed())
+		Expect(a.Members()).To(Equal(2))
+
+		a.Client = interceptor.NewClient(k8sClient.(client.WithWatch), interceptor.Funcs{
+			Update: func(context.Context, client.WithWatch, client.Object, ...client.UpdateOption) error {
+				return errors.New("unavailable")
+			},
+		})
+		// The Lease is still live
+		Expect(a.Sync(ctx, now.Add(10*time.Second))).NotTo(Succeed())
+		Expect(owned(a)).NotTo(BeEmpty())
+
+		// The Lease expired, the namespaces were handed over to b
+		Expect(a.Sync(ctx, now.Add(30*time.Second))).NotTo(Succeed())
+		Expect(owned(a)).To(BeEmpty())
+		Expect(a.Members()).To(Equal(1))
+	})
+
+	It("releases its Lease", func() {
+		a := coordinator("a")
+		Expect(a.Sync(ctx, now)).To(Succeed())
+		Expect(a.release()).To(Succeed())
+
+		var leases coordinationv1.LeaseList
+		Expect(k8sClient.List(ctx, &leases)).To(Succeed())
+		Expect(leases.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,34 @@
+package sharding
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// OwnedNamespacesMetric counts the namespaces owned by the shard of the replica
+	OwnedNamespacesMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_shard_owned_namespaces",
+			Help: "Number of namespaces whose PolicyReports are reconciled by the shard",
+		}, []string{"shard"},
+	)
+	// MembersMetric is the number of live replicas sharing the namespaces
+	MembersMetric = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "exception_recommender_shard_members",
+			Help: "Number of live replicas the namespaces are sharded across",
+		},
+	)
+	// RebalancesMetric counts the membership changes which moved namespaces between shards
+	RebalancesMetric = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "exception_recommender_shard_rebalances_total",
+			Help: "Number of changes of the replicas the namespaces are sharded across",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(OwnedNamespacesMetric, MembersMetric, RebalancesMetric)
+}
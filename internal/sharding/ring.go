@@ -0,0 +1,64 @@
+package sharding
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+	"strconv"
+)
+
+// DefaultVirtualNodes is the number of points of every member on the Ring, which evens out the shards
+const DefaultVirtualNodes = 128
+
+// Ring is a consistent hash ring assigning keys to members. Adding or removing a member only moves
+// the keys of its own points, the other keys keep their owner.
+type Ring struct {
+	members []string
+	points  []uint64
+	owners  map[uint64]string
+}
+
+// NewRing returns a Ring of the members with virtualNodes points each.
+func NewRing(members []string, virtualNodes int) *Ring {
+	r := &Ring{
+		members: slices.Sorted(slices.Values(members)),
+		owners:  make(map[uint64]string),
+	}
+	r.members = slices.Compact(r.members)
+	for _, member := range r.members {
+		for i := 0; i < virtualNodes; i++ {
+			point := hash(member + "#" + strconv.Itoa(i))
+			// Collisions are won by the first member in alphabetical order, on every replica
+			if _, ok := r.owners[point]; ok {
+				continue
+			}
+			r.owners[point] = member
+			r.points = append(r.points, point)
+		}
+	}
+	slices.Sort(r.points)
+
+	return r
+}
+
+// Members returns the sorted members of the Ring.
+func (r *Ring) Members() []string {
+	return r.members
+}
+
+// Owner returns the member owning the key, or an empty string if the Ring has no member.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	i, _ := slices.BinarySearch(r.points, hash(key))
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+func hash(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,57 @@
+package sharding
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ring", func() {
+	namespaces := make([]string, 3000)
+	for i := range namespaces {
+		namespaces[i] = fmt.Sprintf("namespace-%d", i)
+	}
+	owners := func(ring *Ring) map[string]string {
+		owners := make(map[string]string)
+		for _, namespace := range namespaces {
+			owners[namespace] = ring.Owner(namespace)
+		}
+		return owners
+	}
+
+	It("has no owner without members", func() {
+		Expect(NewRing(nil, DefaultVirtualNodes).Owner("default")).To(BeEmpty())
+	})
+
+	It("assigns keys regardless of the order of the members", func() {
+		ring := NewRing([]string{"b", "a", "c", "a"}, DefaultVirtualNodes)
+		Expect(ring.Members()).To(Equal([]string{"a", "b", "c"}))
+		Expect(owners(ring)).To(Equal(owners(NewRing([]string{"c", "a", "b"}, DefaultVirtualNodes))))
+	})
+
+	It("balances the keys across the members", func() {
+		counts := make(map[string]int)
+		for _, owner := range owners(NewRing([]string{"a", "b", "c"}, DefaultVirtualNodes)) {
+			counts[owner]++
+		}
+		Expect(counts).To(HaveLen(3))
+		for _, count := range counts {
+			Expect(count).To(BeNumerically("~", len(namespaces)/3, len(namespaces)/10))
+		}
+	})
+
+	It("only moves keys to a new member", func() {
+		before := owners(NewRing([]string{"a", "b", "c"}, DefaultVirtualNodes))
+		after := owners(NewRing([]string{"a", "b", "c", "d"}, DefaultVirtualNodes))
+
+		moved := 0
+		for namespace, owner := range after {
+			if owner != before[namespace] {
+				Expect(owner).To(Equal("d"))
+				moved++
+			}
+		}
+		Expect(moved).To(BeNumerically("~", len(namespaces)/4, len(namespaces)/10))
+	})
+})
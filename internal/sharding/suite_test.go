@@ -0,0 +1,14 @@
+package sharding
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSharding(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Sharding Suite")
+}
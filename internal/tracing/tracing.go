@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TracerName  = "github.com/giantswarm/exception-recommender"
+	ServiceName = "exception-recommender"
+)
+
+// Span attributes
+const (
+	WorkloadNamespaceKey  = attribute.Key("workload.namespace")
+	WorkloadKindKey       = attribute.Key("workload.kind")
+	WorkloadNameKey       = attribute.Key("workload.name")
+	PoliciesKey           = attribute.Key("policies")
+	PolicyKey             = attribute.Key("policy")
+	PolicyManifestModeKey = attribute.Key("policymanifest.mode")
+	ObjectKindKey         = attribute.Key("object.kind")
+	ObjectNamespaceKey    = attribute.Key("object.namespace")
+	ObjectNameKey         = attribute.Key("object.name")
+	OperationKey          = attribute.Key("operation")
+)
+
+// Setup exports the spans over OTLP/gRPC to the endpoint. The standard OTEL_EXPORTER_OTLP_* environment
+// variables are honoured, tracing stays disabled when neither the endpoint nor the environment is set.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, endpoint string, insecure bool) (func(context.Context) error, error) {
+	if endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var options []otlptracegrpc.Option
+	if endpoint != "" {
+		options = append(options, otlptracegrpc.WithEndpoint(endpoint))
+	}
+	if insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span from the global TracerProvider, which doesn't record anything unless Setup enabled it.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records the error on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
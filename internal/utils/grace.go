@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	CleanSinceAnnotation      = "policy.giantswarm.io/clean-since"
+	CleanReconcilesAnnotation = "policy.giantswarm.io/clean-reconciles"
+)
+
+// DeletionGrace holds how long the results of a workload must be clean before its AutomatedException is deleted.
+// A zero value deletes AutomatedExceptions as soon as the results are clean.
+type DeletionGrace struct {
+	Reconciles int
+	Period     time.Duration
+}
+
+// Observe records a clean reconciliation on the annotations and returns whether the grace period has elapsed,
+// along with the remaining time of the grace period.
+func (g DeletionGrace) Observe(annotations map[string]string, now time.Time) (bool, time.Duration) {
+	cleanSince, err := time.Parse(time.RFC3339, annotations[CleanSinceAnnotation])
+	if err != nil {
+		cleanSince = now
+		annotations[CleanSinceAnnotation] = now.UTC().Format(time.RFC3339)
+	}
+
+	cleanReconciles, err := strconv.Atoi(annotations[CleanReconcilesAnnotation])
+	if err != nil {
+		cleanReconciles = 0
+	}
+	cleanReconciles++
+	annotations[CleanReconcilesAnnotation] = strconv.Itoa(cleanReconciles)
+
+	remaining := g.Period - now.Sub(cleanSince)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return cleanReconciles >= g.Reconciles && remaining == 0, remaining
+}
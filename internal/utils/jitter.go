@@ -2,52 +2,45 @@ package utils
 
 import (
 	"fmt"
-	"math/rand"
+	"math"
+	"math/rand/v2"
 	"time"
-
-	"github.com/go-logr/logr"
-	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// JitterRequeue accepts a default requeue time, a maximum percentage to jitter, and a logger,
-// and returns a Result containing a requeue time which has been randomized with jitter.
-func JitterRequeue(defaultDuration time.Duration, maxJitterPercent int, log logr.Logger) reconcile.Result {
-
-	after, err := Jitter(defaultDuration, maxJitterPercent)
-	if err != nil {
-		log.Error(err, "Failed to calculate jitter")
-		after = defaultDuration
-	}
+// MaxJitterPercent is the largest jitter, beyond which durations could become negative
+const MaxJitterPercent = 100
 
-	return ctrl.Result{
-		Requeue:      true,
-		RequeueAfter: after,
+// ValidateJitterPercent returns an error unless the jitter percentage is between 0 and MaxJitterPercent.
+func ValidateJitterPercent(maxJitterPercent int) error {
+	if maxJitterPercent < 0 || maxJitterPercent > MaxJitterPercent {
+		return fmt.Errorf("jitter must be between 0 and %d%%, got %d%%", MaxJitterPercent, maxJitterPercent)
 	}
+	return nil
 }
 
 // Jitter accepts a Duration and maximum percentage to jitter (as an int),
 // and returns a random Duration in the range t +/- maxJitterPercent.
+// Durations too short to be jittered are returned as is.
 func Jitter(t time.Duration, maxJitterPercent int) (time.Duration, error) {
-	// Get the maximum jitter length as a duration.
-	// Max = t * maxJitterPercent / 100.
-
-	// Decimal representation of the maximum jitter. E.g. 25% --> 0.25.
-	jitterMultiplier := float64(maxJitterPercent) / 100.00
-
-	// Maximum length of time, in milliseconds, which we can add or subtract from our target time.
-	jitterDuration := int64(
-		float64(t.Milliseconds()) * jitterMultiplier)
-
-	// Maximum length of jitter time as a Go Duration.
-	maxJitter, err := time.ParseDuration(fmt.Sprintf("%dms", jitterDuration))
-	if err != nil {
+	if err := ValidateJitterPercent(maxJitterPercent); err != nil {
 		return t, err
 	}
+	if t < 0 {
+		return t, fmt.Errorf("can't jitter negative duration %s", t)
+	}
 
-	// Calcluate the minimum time we have to wait.
-	minDuration := t - maxJitter
+	// Maximum length of time which we can add or subtract from our target time, computed without overflowing.
+	maxJitter := t/100*time.Duration(maxJitterPercent) + t%100*time.Duration(maxJitterPercent)/100
+	if maxJitter == 0 {
+		return t, nil
+	}
+
+	lower := t - maxJitter
+	upper := t + maxJitter
+	if upper < t {
+		upper = math.MaxInt64
+	}
 
-	// Set the final duration to the min + a random duration between 0 and our max jitter.
-	return minDuration + time.Duration(rand.Int63n(int64(maxJitter))), nil // nolint:gosec // rand not used for crypto.
+	// Set the final duration to the min + a random duration up to twice our max jitter.
+	return lower + time.Duration(rand.Uint64N(uint64(upper-lower)+1)), nil // nolint:gosec // rand not used for crypto.
 }
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"time"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+)
+
+const (
+	ApprovedByAnnotation            = "policy.giantswarm.io/approved-by"
+	ApprovedAtAnnotation            = "policy.giantswarm.io/approved-at"
+	ApprovalJustificationAnnotation = "policy.giantswarm.io/approval-justification"
+	ApprovalStatusAnnotation        = "policy.giantswarm.io/approval-status"
+	SourceExceptionAnnotation       = "policy.giantswarm.io/source-automated-exception"
+
+	ApprovalStatusPromoted = "promoted"
+	ApprovalStatusStale    = "stale"
+)
+
+// IsApproved returns true if the AutomatedException has been approved by a reviewer.
+func IsApproved(automatedException policyAPI.AutomatedException) bool {
+	return automatedException.Annotations[ApprovedByAnnotation] != ""
+}
+
+// TemplatePolicyException returns a PolicyException matching the given approved AutomatedException,
+// recording the approver, approval time and justification as annotations.
+func TemplatePolicyException(automatedException policyAPI.AutomatedException, approvedAt time.Time) policyAPI.PolicyException {
+	// Template PolicyException
+	policyException := policyAPI.PolicyException{}
+	// Set GroupVersionKind
+	policyException.SetGroupVersionKind(policyAPI.GroupVersion.WithKind("PolicyException"))
+	// Keep the AutomatedException Name and Namespace
+	policyException.Name = automatedException.Name
+	policyException.Namespace = automatedException.Namespace
+	// Copy the workload Labels, the PolicyException isn't managed by the recommender
+	policyException.Labels = make(map[string]string)
+	for _, key := range []string{KindLabelName, NamespaceLabelName, NameLabelName} {
+		if value, ok := automatedException.Labels[key]; ok {
+			policyException.Labels[key] = value
+		}
+	}
+	// Record approval details
+	policyException.Annotations = map[string]string{
+		ApprovedByAnnotation:            automatedException.Annotations[ApprovedByAnnotation],
+		ApprovedAtAnnotation:            approvedAt.UTC().Format(time.RFC3339),
+		ApprovalJustificationAnnotation: automatedException.Annotations[ApprovalJustificationAnnotation],
+		SourceExceptionAnnotation:       automatedException.Namespace + "/" + automatedException.Name,
+	}
+	// Set .Spec
+	policyException.Spec.Targets = automatedException.Spec.Targets
+	policyException.Spec.Policies = automatedException.Spec.Policies
+
+	return policyException
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"strings"
+)
+
+// AllowProtectedPoliciesAnnotation on a Namespace lists, comma-separated, the protected Policies
+// AutomatedExceptions may still be drafted for in the namespace.
+const AllowProtectedPoliciesAnnotation = "policy.giantswarm.io/allow-protected-policies"
+
+// AllowedProtectedPolicies returns the protected Policies allowed by the annotations of a Namespace.
+func AllowedProtectedPolicies(annotations map[string]string) []string {
+	var policies []string
+	for _, policy := range strings.Split(annotations[AllowProtectedPoliciesAnnotation], ",") {
+		if policy = strings.TrimSpace(policy); policy != "" {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// RequeueStrategy decides when an object is reconciled again.
+type RequeueStrategy interface {
+	// RequeueAfter returns the delay before reconciling an object again, after the given number of consecutive
+	// requeues, starting from 0.
+	RequeueAfter(attempt int) time.Duration
+}
+
+// FixedRequeue requeues after the same Interval, jittered by +/- JitterPercent.
+type FixedRequeue struct {
+	Interval      time.Duration
+	JitterPercent int
+}
+
+// Validate returns an error if the Interval isn't positive or the jitter is out of range.
+func (f FixedRequeue) Validate() error {
+	if f.Interval <= 0 {
+		return fmt.Errorf("requeue interval must be positive, got %s", f.Interval)
+	}
+	return ValidateJitterPercent(f.JitterPercent)
+}
+
+func (f FixedRequeue) RequeueAfter(int) time.Duration {
+	return jitterOrKeep(f.Interval, f.JitterPercent)
+}
+
+// ExponentialRequeue doubles the delay from Initial up to Max on every consecutive requeue,
+// jittered by +/- JitterPercent.
+type ExponentialRequeue struct {
+	Initial       time.Duration
+	Max           time.Duration
+	JitterPercent int
+}
+
+// Validate returns an error if the delays aren't positive and ordered or the jitter is out of range.
+func (e ExponentialRequeue) Validate() error {
+	if e.Initial <= 0 {
+		return fmt.Errorf("initial requeue delay must be positive, got %s", e.Initial)
+	}
+	if e.Max < e.Initial {
+		return fmt.Errorf("maximum requeue delay %s is shorter than the initial delay %s", e.Max, e.Initial)
+	}
+	return ValidateJitterPercent(e.JitterPercent)
+}
+
+// Backoff returns the delay after the given number of consecutive requeues, before jitter.
+func (e ExponentialRequeue) Backoff(attempt int) time.Duration {
+	delay := min(e.Initial, e.Max)
+	for i := 0; i < attempt && delay < e.Max; i++ {
+		if delay > e.Max/2 {
+			return e.Max
+		}
+		delay *= 2
+	}
+	return delay
+}
+
+func (e ExponentialRequeue) RequeueAfter(attempt int) time.Duration {
+	return jitterOrKeep(e.Backoff(attempt), e.JitterPercent)
+}
+
+// Requeue returns a Result requeueing the object according to the strategy.
+func Requeue(strategy RequeueStrategy, attempt int) ctrl.Result {
+	return ctrl.Result{RequeueAfter: strategy.RequeueAfter(attempt)}
+}
+
+// jitterOrKeep jitters the delay, strategies are validated beforehand so invalid jitter keeps the delay.
+func jitterOrKeep(delay time.Duration, jitterPercent int) time.Duration {
+	jittered, err := Jitter(delay, jitterPercent)
+	if err != nil {
+		return delay
+	}
+	return jittered
+}
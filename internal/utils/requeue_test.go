@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"math"
+	"testing/quick"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Property-based tests draw their inputs at random, these map them onto the valid ranges.
+func anyDuration(raw int64) time.Duration {
+	return time.Duration(raw & math.MaxInt64)
+}
+
+func anyJitterPercent(raw uint8) int {
+	return int(raw) % (MaxJitterPercent + 1)
+}
+
+// withinJitter tells whether the delay is within percent of t, computed in floating point with a rounding tolerance.
+func withinJitter(delay time.Duration, t time.Duration, percent int) bool {
+	maxJitter := float64(t) * float64(percent) / 100
+	tolerance := 1 + float64(t)*1e-12
+	return delay >= 0 && float64(delay) >= float64(t)-maxJitter-tolerance && float64(delay) <= float64(t)+maxJitter+tolerance
+}
+
+var _ = Describe("Jitter", func() {
+	It("stays within the jitter of any duration", func() {
+		property := func(raw int64, rawPercent uint8) bool {
+			t, percent := anyDuration(raw), anyJitterPercent(rawPercent)
+			jittered, err := Jitter(t, percent)
+			return err == nil && withinJitter(jittered, t, percent)
+		}
+		Expect(quick.Check(property, &quick.Config{MaxCount: 10000})).To(Succeed())
+	})
+
+	It("handles tiny durations", func() {
+		property := func(raw uint8, rawPercent uint8) bool {
+			t, percent := time.Duration(raw%100), anyJitterPercent(rawPercent)
+			jittered, err := Jitter(t, percent)
+			return err == nil && withinJitter(jittered, t, percent)
+		}
+		Expect(quick.Check(property, nil)).To(Succeed())
+	})
+
+	It("keeps durations without jitter", func() {
+		property := func(raw int64) bool {
+			t := anyDuration(raw)
+			jittered, err := Jitter(t, 0)
+			return err == nil && jittered == t
+		}
+		Expect(quick.Check(property, nil)).To(Succeed())
+	})
+
+	It("rejects invalid jitter and durations", func() {
+		property := func(raw int64, percent int) bool {
+			t := anyDuration(raw)
+			jittered, err := Jitter(t, percent)
+			if percent < 0 || percent > MaxJitterPercent {
+				return err != nil && jittered == t
+			}
+			return err == nil
+		}
+		Expect(quick.Check(property, nil)).To(Succeed())
+
+		_, err := Jitter(-time.Second, 10)
+		Expect(err).To(HaveOccurred())
+		Expect(ValidateJitterPercent(101)).NotTo(Succeed())
+		Expect(ValidateJitterPercent(-1)).NotTo(Succeed())
+		Expect(ValidateJitterPercent(100)).To(Succeed())
+	})
+})
+
+var _ = Describe("RequeueStrategy", func() {
+	It("requeues after a fixed interval", func() {
+		property := func(raw int64, rawPercent uint8, attempt int) bool {
+			strategy := FixedRequeue{Interval: anyDuration(raw), JitterPercent: anyJitterPercent(rawPercent)}
+			return withinJitter(strategy.RequeueAfter(attempt), strategy.Interval, strategy.JitterPercent)
+		}
+		Expect(quick.Check(property, &quick.Config{MaxCount: 10000})).To(Succeed())
+	})
+
+	It("backs off exponentially up to the maximum", func() {
+		property := func(rawInitial int64, rawMax int64, attempt uint16) bool {
+			initial, max := anyDuration(rawInitial)|1, anyDuration(rawMax)
+			if max < initial {
+				initial, max = max|1, initial
+			}
+			strategy := ExponentialRequeue{Initial: initial, Max: max}
+
+			delay, next := strategy.Backoff(int(attempt)), strategy.Backoff(int(attempt)+1)
+			return delay >= initial && delay <= max && next >= delay && (next == max || next == 2*delay)
+		}
+		Expect(quick.Check(property, &quick.Config{MaxCount: 10000})).To(Succeed())
+
+		strategy := ExponentialRequeue{Initial: 15 * time.Second, Max: time.Minute}
+		Expect([]time.Duration{strategy.Backoff(0), strategy.Backoff(1), strategy.Backoff(2), strategy.Backoff(3), strategy.Backoff(math.MaxInt)}).
+			To(Equal([]time.Duration{15 * time.Second, 30 * time.Second, time.Minute, time.Minute, time.Minute}))
+	})
+
+	It("jitters exponential backoffs", func() {
+		property := func(attempt uint8, rawPercent uint8) bool {
+			strategy := ExponentialRequeue{Initial: time.Second, Max: time.Hour, JitterPercent: anyJitterPercent(rawPercent)}
+			return withinJitter(strategy.RequeueAfter(int(attempt)), strategy.Backoff(int(attempt)), strategy.JitterPercent)
+		}
+		Expect(quick.Check(property, nil)).To(Succeed())
+		Expect(Requeue(ExponentialRequeue{Initial: time.Second, Max: time.Minute}, 3).RequeueAfter).To(Equal(8 * time.Second))
+	})
+
+	It("validates the strategies", func() {
+		Expect(FixedRequeue{Interval: time.Minute, JitterPercent: 10}.Validate()).To(Succeed())
+		Expect(FixedRequeue{Interval: 0}.Validate()).NotTo(Succeed())
+		Expect(FixedRequeue{Interval: time.Minute, JitterPercent: 150}.Validate()).NotTo(Succeed())
+
+		Expect(ExponentialRequeue{Initial: time.Second, Max: time.Minute, JitterPercent: 10}.Validate()).To(Succeed())
+		Expect(ExponentialRequeue{Initial: 0, Max: time.Minute}.Validate()).NotTo(Succeed())
+		Expect(ExponentialRequeue{Initial: time.Minute, Max: time.Second}.Validate()).NotTo(Succeed())
+		Expect(ExponentialRequeue{Initial: time.Second, Max: time.Minute, JitterPercent: -5}.Validate()).NotTo(Succeed())
+	})
+})
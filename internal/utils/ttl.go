@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ExpiresAtAnnotation    = "policy.giantswarm.io/expires-at"
+	ExpiredAnnotation      = "policy.giantswarm.io/expired"
+	RenewRequestAnnotation = "policy.giantswarm.io/renew"
+	// ExpiredPoliciesAnnotation holds the comma-separated Policies revoked from an AutomatedException on expiry
+	ExpiredPoliciesAnnotation = "policy.giantswarm.io/expired-policies"
+
+	ExpiredActionMark   = "mark"
+	ExpiredActionDelete = "delete"
+)
+
+// ExceptionTTL holds the time to live of AutomatedExceptions.
+// Overrides are keyed by Policy name or Policy category, Policy names take precedence.
+type ExceptionTTL struct {
+	Default   time.Duration
+	Overrides map[string]time.Duration
+}
+
+// Enabled returns true if any TTL has been configured.
+func (t ExceptionTTL) Enabled() bool {
+	return t.Default > 0 || len(t.Overrides) != 0
+}
+
+// For accepts a map of failed Policies to their category and returns the shortest TTL among them.
+// A zero Duration means the AutomatedException never expires.
+func (t ExceptionTTL) For(policyCategories map[string]string) time.Duration {
+	var ttl time.Duration
+
+	for policy, category := range policyCategories {
+		policyTTL := t.Default
+		if override, ok := t.Overrides[category]; ok {
+			policyTTL = override
+		}
+		if override, ok := t.Overrides[policy]; ok {
+			policyTTL = override
+		}
+
+		if policyTTL > 0 && (ttl == 0 || policyTTL < ttl) {
+			ttl = policyTTL
+		}
+	}
+
+	return ttl
+}
+
+// ParseTTLOverride parses a comma-separated list of 'policy-or-category=duration' pairs into overrides.
+func ParseTTLOverride(input string, overrides map[string]time.Duration) error {
+	for _, item := range strings.Split(input, ",") {
+		// Categories may contain '=' characters, split on the last one
+		index := strings.LastIndex(item, "=")
+		if index <= 0 {
+			return fmt.Errorf("invalid TTL override %q, expected 'policy-or-category=duration'", item)
+		}
+
+		duration, err := time.ParseDuration(item[index+1:])
+		if err != nil {
+			return fmt.Errorf("invalid TTL override %q: %w", item, err)
+		}
+
+		overrides[strings.TrimSpace(item[:index])] = duration
+	}
+
+	return nil
+}
+
+// IsExpired returns true if the annotations mark the object as expired or its expiry time has passed.
+func IsExpired(annotations map[string]string, now time.Time) bool {
+	if annotations[ExpiredAnnotation] == "true" {
+		return true
+	}
+	expiresAt, ok := ExpiresAt(annotations)
+	return ok && !now.Before(expiresAt)
+}
+
+// ExpiresAt returns the expiry time stamped on an object's annotations, if any.
+func ExpiresAt(annotations map[string]string) (time.Time, bool) {
+	value, ok := annotations[ExpiresAtAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return expiresAt, true
+}
@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+// ValidatePath is where the AutomatedException webhook is served
+const ValidatePath = "/validate-policy-giantswarm-io-v1alpha1-automatedexception"
+
+// Results of the WebhookRequestsMetric
+const (
+	ResultAllowed = "allowed"
+	ResultDenied  = "denied"
+)
+
+// DefaultAllowedUsers may delete managed AutomatedExceptions along with their namespace or owner
+var DefaultAllowedUsers = []string{
+	"system:serviceaccount:kube-system:generic-garbage-collector",
+	"system:serviceaccount:kube-system:namespace-controller",
+}
+
+// ReviewAnnotations may be changed by anyone on managed AutomatedExceptions, except the ApprovedByAnnotation
+// which may only be set to the requesting user. The approval time and status are only written by the recommender.
+var ReviewAnnotations = []string{
+	utils.ApprovedByAnnotation,
+	utils.ApprovalJustificationAnnotation,
+	utils.RenewRequestAnnotation,
+}
+
+// +kubebuilder:webhook:path=/validate-policy-giantswarm-io-v1alpha1-automatedexception,mutating=false,failurePolicy=fail,sideEffects=None,groups=policy.giantswarm.io,resources=automatedexceptions,verbs=create;update;delete,versions=v1alpha1,name=vautomatedexception.policy.giantswarm.io,admissionReviewVersions=v1
+
+// AutomatedExceptionValidator rejects the changes to the managed fields of the AutomatedExceptions labelled as
+// managed by the exception-recommender, unless requested by one of the AllowedUsers, e.g. the recommender itself.
+// The managed fields are the spec, the labels and the annotations other than the ReviewAnnotations.
+// Every attempted change by another user is logged and counted.
+type AutomatedExceptionValidator struct {
+	AllowedUsers []string
+	Log          logr.Logger
+}
+
+// SetupWithManager registers the webhook with the webhook server of the Manager.
+func (v *AutomatedExceptionValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &policyAPI.AutomatedException{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *AutomatedExceptionValidator) ValidateCreate(ctx context.Context, automatedException *policyAPI.AutomatedException) (admission.Warnings, error) {
+	if !isManaged(automatedException) {
+		return nil, nil
+	}
+	return nil, v.audit(ctx, "create", nil, automatedException, []string{"metadata.labels"})
+}
+
+func (v *AutomatedExceptionValidator) ValidateUpdate(ctx context.Context, oldAutomatedException *policyAPI.AutomatedException, newAutomatedException *policyAPI.AutomatedException) (admission.Warnings, error) {
+	if !isManaged(oldAutomatedException) && !isManaged(newAutomatedException) {
+		return nil, nil
+	}
+	return nil, v.audit(ctx, "update", oldAutomatedException, newAutomatedException, ManagedChanges(oldAutomatedException, newAutomatedException))
+}
+
+func (v *AutomatedExceptionValidator) ValidateDelete(ctx context.Context, automatedException *policyAPI.AutomatedException) (admission.Warnings, error) {
+	if !isManaged(automatedException) {
+		return nil, nil
+	}
+	return nil, v.audit(ctx, "delete", nil, automatedException, []string{"metadata"})
+}
+
+// audit allows the request if it was sent by an allowed user or doesn't change any managed field, and only
+// approves in the name of the requesting user. oldAutomatedException is only set on updates.
+func (v *AutomatedExceptionValidator) audit(ctx context.Context, operation string, oldAutomatedException *policyAPI.AutomatedException, automatedException *policyAPI.AutomatedException, changes []string) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	user := req.UserInfo.Username
+	if slices.Contains(v.AllowedUsers, user) {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	if logger.GetSink() == nil {
+		logger = v.Log
+	}
+	logger = logger.WithValues("user", user, "operation", operation, "automatedException", fmt.Sprintf("%s/%s", automatedException.Namespace, automatedException.Name))
+
+	if oldAutomatedException != nil {
+		approver := automatedException.Annotations[utils.ApprovedByAnnotation]
+		if approver != oldAutomatedException.Annotations[utils.ApprovedByAnnotation] && approver != user {
+			logger.Info("Denied approval of managed AutomatedException in the name of another user", "approver", approver)
+			WebhookRequestsMetric.WithLabelValues(operation, ResultDenied).Inc()
+			return fmt.Errorf("the %s annotation may only be set to the requesting user %s", utils.ApprovedByAnnotation, user)
+		}
+	}
+
+	if len(changes) == 0 {
+		logger.Info("Allowed review of managed AutomatedException")
+		WebhookRequestsMetric.WithLabelValues(operation, ResultAllowed).Inc()
+		return nil
+	}
+
+	logger.Info("Denied change to managed AutomatedException", "changes", changes)
+	WebhookRequestsMetric.WithLabelValues(operation, ResultDenied).Inc()
+	return fmt.Errorf("AutomatedException is managed by the exception-recommender, %s of %s is not allowed, only the %s annotations may be changed",
+		operation, strings.Join(changes, ", "), strings.Join(ReviewAnnotations, ", "))
+}
+
+// ManagedChanges lists the managed fields which differ between both AutomatedExceptions.
+func ManagedChanges(oldAutomatedException *policyAPI.AutomatedException, newAutomatedException *policyAPI.AutomatedException) []string {
+	var changes []string
+	if !equality.Semantic.DeepEqual(oldAutomatedException.Spec, newAutomatedException.Spec) {
+		changes = append(changes, "spec")
+	}
+	if !equality.Semantic.DeepEqual(oldAutomatedException.Labels, newAutomatedException.Labels) {
+		changes = append(changes, "metadata.labels")
+	}
+
+	keys := make(map[string]bool)
+	for key := range oldAutomatedException.Annotations {
+		keys[key] = true
+	}
+	for key := range newAutomatedException.Annotations {
+		keys[key] = true
+	}
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		if isReviewAnnotation(key) {
+			continue
+		}
+		oldValue, oldOk := oldAutomatedException.Annotations[key]
+		newValue, newOk := newAutomatedException.Annotations[key]
+		if oldOk != newOk || oldValue != newValue {
+			changes = append(changes, fmt.Sprintf("metadata.annotations[%s]", key))
+		}
+	}
+
+	return changes
+}
+
+func isManaged(automatedException *policyAPI.AutomatedException) bool {
+	return automatedException.Labels[utils.AppLabelName] == utils.ComponentName
+}
+
+func isReviewAnnotation(key string) bool {
+	return slices.Contains(ReviewAnnotations, key)
+}
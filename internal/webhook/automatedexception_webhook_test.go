@@ -0,0 +1,284 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
+
+	utils "github.com/giantswarm/exception-recommender/internal/utils"
+)
+
+const recommenderUser = "system:serviceaccount:security:exception-recommender"
+
+var _ = Describe("AutomatedException webhook", func() {
+	validator := &AutomatedExceptionValidator{
+		AllowedUsers: append([]string{recommenderUser}, DefaultAllowedUsers...),
+		Log:          logr.Discard(),
+	}
+
+	managed := func() *policyAPI.AutomatedException {
+		return &policyAPI.AutomatedException{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "deployment-api",
+				Namespace:   "team-a",
+				Labels:      map[string]string{utils.AppLabelName: utils.ComponentName},
+				Annotations: map[string]string{utils.ExpiresAtAnnotation: "2026-12-01T00:00:00Z"},
+			},
+			Spec: policyAPI.AutomatedExceptionSpec{Policies: []string{"require-labels"}},
+		}
+	}
+	as := func(user string, operation admissionv1.Operation) context.Context {
+		return admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			UserInfo:  authenticationv1.UserInfo{Username: user},
+		}})
+	}
+
+	It("allows the recommender to change managed AutomatedExceptions", func() {
+		changed := managed()
+		changed.Spec.Policies = append(changed.Spec.Policies, "require-run-as-nonroot")
+
+		_, err := validator.ValidateCreate(as(recommenderUser, admissionv1.Create), managed())
+		Expect(err).NotTo(HaveOccurred())
+		_, err = validator.ValidateUpdate(as(recommenderUser, admissionv1.Update), managed(), changed)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = validator.ValidateDelete(as(recommenderUser, admissionv1.Delete), managed())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("allows the garbage collector to delete managed AutomatedExceptions", func() {
+		_, err := validator.ValidateDelete(as("system:serviceaccount:kube-system:generic-garbage-collector", admissionv1.Delete), managed())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("ignores AutomatedExceptions not managed by the recommender", func() {
+		unmanaged := managed()
+		unmanaged.Labels = nil
+		changed := unmanaged.DeepCopy()
+		changed.Spec.Policies = nil
+
+		_, err := validator.ValidateCreate(as("alice", admissionv1.Create), unmanaged)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = validator.ValidateUpdate(as("alice", admissionv1.Update), unmanaged, changed)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = validator.ValidateDelete(as("alice", admissionv1.Delete), unmanaged)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("allows other users to review managed AutomatedExceptions", func() {
+		reviewed := managed()
+		reviewed.Annotations[utils.ApprovedByAnnotation] = "alice"
+		reviewed.Annotations[utils.ApprovalJustificationAnnotation] = "legacy workload"
+		reviewed.Annotations[utils.RenewRequestAnnotation] = "true"
+		reviewed.Finalizers = []string{"example.com/finalizer"}
+		allowed := testutil.ToFloat64(WebhookRequestsMetric.WithLabelValues("update", ResultAllowed))
+
+		_, err := validator.ValidateUpdate(as("alice", admissionv1.Update), managed(), reviewed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(WebhookRequestsMetric.WithLabelValues("update", ResultAllowed))).To(Equal(allowed + 1))
+	})
+
+	It("rejects changes of other users to the managed fields", func() {
+		denied := testutil.ToFloat64(WebhookRequestsMetric.WithLabelValues("update", ResultDenied))
+
+		changedSpec := managed()
+		changedSpec.Spec.Policies = nil
+		_, err := validator.ValidateUpdate(as("alice", admissionv1.Update), managed(), changedSpec)
+		Expect(err).To(MatchError(ContainSubstring("update of spec is not allowed")))
+
+		unlabelled := managed()
+		unlabelled.Labels = nil
+		_, err = validator.ValidateUpdate(as("alice", admissionv1.Update), managed(), unlabelled)
+		Expect(err).To(MatchError(ContainSubstring("metadata.labels")))
+
+		changedAnnotation := managed()
+		changedAnnotation.Annotations[utils.ExpiresAtAnnotation] = "2030-01-01T00:00:00Z"
+		_, err = validator.ValidateUpdate(as("alice", admissionv1.Update), managed(), changedAnnotation)
+		Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("metadata.annotations[%s]", utils.ExpiresAtAnnotation))))
+
+		Expect(testutil.ToFloat64(WebhookRequestsMetric.WithLabelValues("update", ResultDenied))).To(Equal(denied + 3))
+	})
+
+	It("rejects forged approvals", func() {
+		denied := testutil.ToFloat64(WebhookRequestsMetric.WithLabelValues("update", ResultDenied))
+
+		promoted := managed()
+		promoted.Annotations[utils.ApprovalStatusAnnotation] = "promoted"
+		_, err := validator.ValidateUpdate(as("alice", admissionv1.Update), managed(), promoted)
+		Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("metadata.annotations[%s]", utils.ApprovalStatusAnnotation))))
+
+		stale := managed()
+		stale.Annotations[utils.ApprovalStatusAnnotation] = "stale"
+		_, err = validator.ValidateUpdate(as("alice", admissionv1.Update), stale, managed())
+		Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("metadata.annotations[%s]", utils.ApprovalStatusAnnotation))))
+
+		approvedByBob := managed()
+		approvedByBob.Annotations[utils.ApprovedByAnnotation] = "bob"
+		_, err = validator.ValidateUpdate(as("alice", admissionv1.Update), managed(), approvedByBob)
+		Expect(err).To(MatchError(ContainSubstring("may only be set to the requesting user alice")))
+
+		// Nor may the approval of another user be taken over or withdrawn
+		approvedByAlice := managed()
+		approvedByAlice.Annotations[utils.ApprovedByAnnotation] = "alice"
+		_, err = validator.ValidateUpdate(as("mallory", admissionv1.Update), approvedByAlice, approvedByBob)
+		Expect(err).To(HaveOccurred())
+		_, err = validator.ValidateUpdate(as("mallory", admissionv1.Update), approvedByAlice, managed())
+		Expect(err).To(HaveOccurred())
+
+		Expect(testutil.ToFloat64(WebhookRequestsMetric.WithLabelValues("update", ResultDenied))).To(Equal(denied + 5))
+	})
+
+	It("allows reviewers to amend their approval", func() {
+		approved := managed()
+		approved.Annotations[utils.ApprovedByAnnotation] = "alice"
+		justified := approved.DeepCopy()
+		justified.Annotations[utils.ApprovalJustificationAnnotation] = "legacy workload"
+
+		_, err := validator.ValidateUpdate(as("alice", admissionv1.Update), approved, justified)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects creations and deletions of managed AutomatedExceptions by other users", func() {
+		_, err := validator.ValidateCreate(as("alice", admissionv1.Create), managed())
+		Expect(err).To(HaveOccurred())
+		_, err = validator.ValidateDelete(as("alice", admissionv1.Delete), managed())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("lists the managed changes", func() {
+		changed := managed()
+		changed.Spec.Policies = nil
+		changed.Annotations["b"] = "added"
+		changed.Annotations["a"] = "added"
+		changed.Annotations[utils.ApprovalJustificationAnnotation] = "legacy workload"
+		changed.Annotations[utils.ApprovalStatusAnnotation] = "promoted"
+		delete(changed.Annotations, utils.ExpiresAtAnnotation)
+
+		Expect(ManagedChanges(managed(), changed)).To(Equal([]string{
+			"spec",
+			"metadata.annotations[a]",
+			"metadata.annotations[b]",
+			fmt.Sprintf("metadata.annotations[%s]", utils.ApprovalStatusAnnotation),
+			fmt.Sprintf("metadata.annotations[%s]", utils.ExpiresAtAnnotation),
+		}))
+	})
+
+	It("serves admission reviews over TLS", func() {
+		certDir := GinkgoT().TempDir()
+		caPool := writeSelfSignedCertificate(certDir)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		port := listener.Addr().(*net.TCPAddr).Port
+		Expect(listener.Close()).To(Succeed())
+
+		scheme := runtime.NewScheme()
+		Expect(policyAPI.AddToScheme(scheme)).To(Succeed())
+		server := ctrlwebhook.NewServer(ctrlwebhook.Options{Host: "127.0.0.1", Port: port, CertDir: certDir})
+		server.Register(ValidatePath, admission.WithValidator(scheme, validator))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			defer GinkgoRecover()
+			Expect(server.Start(ctx)).To(Succeed())
+		}()
+
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: caPool, MinVersion: tls.VersionTLS12}}}
+		review := func(user string) *admissionv1.AdmissionResponse {
+			changed := managed()
+			changed.Spec.Policies = nil
+			oldObject, err := json.Marshal(managed())
+			Expect(err).NotTo(HaveOccurred())
+			object, err := json.Marshal(changed)
+			Expect(err).NotTo(HaveOccurred())
+
+			body, err := json.Marshal(admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       "1",
+					Kind:      metav1.GroupVersionKind{Group: policyAPI.GroupVersion.Group, Version: policyAPI.GroupVersion.Version, Kind: "AutomatedException"},
+					Operation: admissionv1.Update,
+					UserInfo:  authenticationv1.UserInfo{Username: user},
+					Object:    runtime.RawExtension{Raw: object},
+					OldObject: runtime.RawExtension{Raw: oldObject},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var response *http.Response
+			Eventually(func() error {
+				response, err = httpClient.Post(fmt.Sprintf("https://localhost:%d%s", port, ValidatePath), "application/json", bytes.NewReader(body))
+				return err
+			}).Should(Succeed())
+			defer response.Body.Close() // nolint:errcheck
+
+			var result admissionv1.AdmissionReview
+			Expect(json.NewDecoder(response.Body).Decode(&result)).To(Succeed())
+			return result.Response
+		}
+
+		Expect(review(recommenderUser).Allowed).To(BeTrue())
+		denied := review("alice")
+		Expect(denied.Allowed).To(BeFalse())
+		Expect(denied.Result.Message).To(ContainSubstring("managed by the exception-recommender"))
+	})
+})
+
+// writeSelfSignedCertificate writes a tls.crt and tls.key for localhost into dir and returns a pool trusting it.
+func writeSelfSignedCertificate(dir string) *x509.CertPool {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(os.WriteFile(filepath.Join(dir, "tls.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(dir, "tls.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)).To(Succeed())
+
+	certificate, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return pool
+}
@@ -0,0 +1,17 @@
+package webhook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var WebhookRequestsMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "exception_recommender_webhook_requests_total",
+		Help: "Number of attempted changes to managed AutomatedExceptions by users other than the recommender",
+	}, []string{"operation", "result"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(WebhookRequestsMetric)
+}
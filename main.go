@@ -17,28 +17,55 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	kyverno "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	policyAPI "github.com/giantswarm/policy-api/api/v1alpha1"
 
+	recommenderAPI "github.com/giantswarm/exception-recommender/api/v1alpha1"
+	"github.com/giantswarm/exception-recommender/internal/audit"
 	"github.com/giantswarm/exception-recommender/internal/controller"
+	"github.com/giantswarm/exception-recommender/internal/history"
+	"github.com/giantswarm/exception-recommender/internal/offline"
+	"github.com/giantswarm/exception-recommender/internal/sharding"
+	"github.com/giantswarm/exception-recommender/internal/tracing"
+	"github.com/giantswarm/exception-recommender/internal/utils"
+	"github.com/giantswarm/exception-recommender/internal/webhook"
 	//+kubebuilder:scaffold:imports
 )
 
+const (
+	historyBackendFile      = "file"
+	historyBackendConfigMap = "configmap"
+)
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -53,30 +80,84 @@ func init() {
 	}
 
 	utilruntime.Must(policyAPI.AddToScheme(scheme))
+	utilruntime.Must(recommenderAPI.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
+	// Compute recommendations from files without connecting to the API server
+	if len(os.Args) > 1 {
+		subcommands := map[string]func([]string, io.Writer) error{
+			"offline": offline.Run,
+			"explain": offline.Explain,
+		}
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			ctrl.SetLogger(zap.New(zap.WriteTo(os.Stderr)))
+			if err := subcommand(os.Args[2:], os.Stdout); err != nil {
+				setupLog.Error(err, "unable to run subcommand", "subcommand", os.Args[1])
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var reportAddr string
+	var reportCertDir string
 	var destinationNamespace string
 	var targetWorkloads []string
 	var targetCategories []string
 	var excludeNamespaces []string
 	var maxJitterPercent int
-	policyManifestCache := make(map[string]policyAPI.PolicyManifest)
+	var policyReportWorkers, automatedExceptionWorkers int
+	var kubeAPIQPS, exceptionWriteQPS float64
+	var kubeAPIBurst, exceptionWriteBurst int
+	var resyncPeriod time.Duration
+	var requeueInterval, manifestBackoff, manifestMaxBackoff time.Duration
+	var expiryWarningWindow time.Duration
+	var expiredAction string
+	var deletionGrace utils.DeletionGrace
+	var dryRun bool
+	var otlpEndpoint string
+	var otlpInsecure bool
+	var protectedPolicies []string
+	var maxExceptionsPerNamespace int
+	var maxExceptionsPerPolicy int
+	var maxCreationsPerMinute int
+	var historyBackend string
+	var historyFile string
+	var historyConfigMap string
+	var historyCapacity int
+	var auditLog string
+	var auditConfigMap string
+	var auditConfigMapMaxDays int
+	var enableWebhook bool
+	var webhookPort int
+	var webhookCertDir string
+	var enableSharding bool
+	var shardLeaseNamespace string
+	var shardIdentity string
+	var shardLeaseDuration time.Duration
+	webhookAllowedUsers := append([]string{}, webhook.DefaultAllowedUsers...)
+	exceptionTTL := utils.ExceptionTTL{Overrides: make(map[string]time.Duration)}
+	policyManifestCache := controller.NewPolicyManifestCache()
 
 	// Flags
 	flag.StringVar(&destinationNamespace, "destination-namespace", "", "The namespace where the PolicyExceptionDrafts will be created. Defaults to resource namespace.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&reportAddr, "report-bind-address", "0", "The address the compliance report endpoint binds to, "+
+		"its requests are authenticated and authorized with TokenReviews and SubjectAccessReviews. Disabled when '0'.")
+	flag.StringVar(&reportCertDir, "report-cert-dir", "",
+		"The directory holding the tls.crt and tls.key the compliance report endpoint is served with. Required by --report-bind-address.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
-	opts := zap.Options{
-		Development: true,
-	}
+	// Logs are JSON encoded at info level by default, --zap-devel switches to the human readable development mode
+	// and --zap-log-level=debug enables the messages logged on every reconciliation.
+	opts := zap.Options{}
 	flag.Func("target-categories",
 		"A comma-separated list of Kyverno Policy Categories to be included in the Draft generation. For example: 'Pod Security Standards'",
 		func(input string) error {
@@ -104,47 +185,472 @@ func main() {
 
 			return nil
 		})
+	flag.Func("protected-policies",
+		"A comma-separated list of Kyverno Policies which are never automatically excepted. "+
+			"A Namespace may allow some of them with the "+utils.AllowProtectedPoliciesAnnotation+" annotation.",
+		func(input string) error {
+			items := strings.Split(input, ",")
+
+			protectedPolicies = append(protectedPolicies, items...)
+
+			return nil
+		})
+	flag.IntVar(&maxExceptionsPerNamespace, "max-exceptions-per-namespace", 0,
+		"Maximum number of AutomatedExceptions for the workloads of a namespace, further creations are skipped. Disabled when 0.")
+	flag.IntVar(&maxExceptionsPerPolicy, "max-exceptions-per-policy", 0,
+		"Maximum number of AutomatedExceptions listing a Policy, further creations are skipped. Disabled when 0.")
+	flag.IntVar(&maxCreationsPerMinute, "max-creations-per-minute", 0,
+		"Maximum number of AutomatedExceptions created within a minute, exceeding it opens the circuit breaker and pauses creation. Disabled when 0.")
+	flag.IntVar(&policyReportWorkers, "policyreport-workers", 4,
+		"Number of PolicyReports reconciled in parallel.")
+	flag.IntVar(&automatedExceptionWorkers, "automatedexception-workers", 2,
+		"Number of AutomatedExceptions reconciled in parallel by the approval and expiry controllers.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20,
+		"Maximum number of requests per second to the API server, sustained by the client.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30,
+		"Maximum number of requests to the API server in a burst above --kube-api-qps.")
+	flag.Float64Var(&exceptionWriteQPS, "exception-write-qps", 10,
+		"Maximum number of AutomatedException writes per second, shared by all the controllers of a replica. Disabled when 0.")
+	flag.IntVar(&exceptionWriteBurst, "exception-write-burst", 20,
+		"Maximum number of AutomatedException writes in a burst above --exception-write-qps.")
 	flag.IntVar(&maxJitterPercent, "max-jitter-percent", 10,
-		"Spreads out re-queue interval of reports by +/- this amount to spread load.")
+		"Spreads out re-queue interval of reports by +/- this amount to spread load, between 0 and 100.")
+	flag.DurationVar(&requeueInterval, "requeue-interval", controller.DefaultRequeueDuration,
+		"How often limited AutomatedException creations and deletions delayed by the grace period are retried.")
+	flag.DurationVar(&resyncPeriod, "resync-period", 10*time.Hour,
+		"How often every watched resource is reconciled again as a safety net, reconciliations are otherwise triggered by changes.")
+	flag.DurationVar(&manifestBackoff, "manifest-missing-backoff", controller.DefaultManifestBackoff,
+		"First delay before retrying a failed Policy without PolicyManifest, doubled on every retry of the Policy.")
+	flag.DurationVar(&manifestMaxBackoff, "manifest-missing-max-backoff", 30*time.Minute,
+		"Maximum delay before retrying a failed Policy without PolicyManifest. The creation of the PolicyManifest retries it right away.")
+	flag.DurationVar(&exceptionTTL.Default, "exception-ttl", 0,
+		"Time to live of the AutomatedExceptions, stamped as an expiry date on creation. Disabled by default.")
+	flag.Func("exception-ttl-overrides",
+		"A comma-separated list of 'policy-or-category=duration' TTL overrides. For example: 'require-run-as-nonroot=720h'",
+		func(input string) error {
+			return utils.ParseTTLOverride(input, exceptionTTL.Overrides)
+		})
+	flag.DurationVar(&expiryWarningWindow, "expiry-warning-window", 72*time.Hour,
+		"How long before expiry a warning Event is emitted for an AutomatedException.")
+	flag.StringVar(&expiredAction, "expired-exception-action", utils.ExpiredActionMark,
+		"What to do with expired AutomatedExceptions: 'mark' them, or 'delete' their Policies. Both keep them until the workload no longer fails.")
+	flag.IntVar(&deletionGrace.Reconciles, "deletion-grace-reconciles", 0,
+		"Number of consecutive clean reconciliations required before an AutomatedException is deleted.")
+	flag.DurationVar(&deletionGrace.Period, "deletion-grace-period", 0,
+		"How long the results must stay clean before an AutomatedException is deleted.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Compute the AutomatedExceptions without writing them. Planned operations are served on /dry-run by the compliance report endpoint.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "",
+		"The OTLP/gRPC endpoint the traces are exported to, e.g. 'otel-collector:4317'. Tracing is disabled unless set here or through OTEL_EXPORTER_OTLP_ENDPOINT.")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false,
+		"Export the traces without TLS.")
+	flag.StringVar(&historyBackend, "history-backend", "",
+		"Where the daily history snapshots are stored: 'file', 'configmap', or empty to disable the history.")
+	flag.StringVar(&historyFile, "history-file", "history.jsonl",
+		"The file the history snapshots are appended to with the 'file' backend.")
+	flag.StringVar(&historyConfigMap, "history-configmap", "",
+		"The 'namespace/name' of the ConfigMap holding the history snapshots with the 'configmap' backend.")
+	flag.IntVar(&historyCapacity, "history-capacity", 90,
+		"Number of daily snapshots kept by the 'configmap' backend.")
+	flag.StringVar(&auditLog, "audit-log", "",
+		"The file the audit records of every AutomatedException change are appended to as JSON lines, '-' for stdout. Disabled when empty.")
+	flag.StringVar(&auditConfigMap, "audit-configmap", "",
+		"The 'namespace/prefix' of the daily ConfigMaps the audit records are appended to. Disabled when empty.")
+	flag.IntVar(&auditConfigMapMaxDays, "audit-configmap-max-days", 90,
+		"Number of days the audit ConfigMaps are kept, they are never pruned when 0.")
+	flag.BoolVar(&enableWebhook, "enable-webhook", false,
+		"Serve the validating webhook rejecting changes to the managed fields of the recommender AutomatedExceptions.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443,
+		"The port the validating webhook binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "",
+		"The directory holding the tls.crt and tls.key of the validating webhook. Defaults to the controller-runtime temporary directory.")
+	flag.BoolVar(&enableSharding, "enable-sharding", false,
+		"Shard the namespaces across the replicas with a consistent hash, each replica reconciles the PolicyReports of its namespaces. Requires --leader-elect.")
+	flag.StringVar(&shardLeaseNamespace, "shard-lease-namespace", os.Getenv("POD_NAMESPACE"),
+		"The namespace of the Leases coordinating the shards. Defaults to the POD_NAMESPACE environment variable.")
+	flag.StringVar(&shardIdentity, "shard-identity", os.Getenv("POD_NAME"),
+		"The identity of the replica in the shards, unique across the replicas. Defaults to the POD_NAME environment variable, or the hostname.")
+	flag.DurationVar(&shardLeaseDuration, "shard-lease-duration", sharding.DefaultLeaseDuration,
+		"How long the namespaces of a replica which stopped renewing its Lease stay unreconciled, Leases are renewed every third of it.")
+	flag.Func("webhook-allowed-users",
+		"A comma-separated list of users allowed to change managed AutomatedExceptions, in addition to the garbage collector and namespace controller. "+
+			"Must include the service account of the recommender.",
+		func(input string) error {
+			items := strings.Split(input, ",")
+
+			webhookAllowedUsers = append(webhookAllowedUsers, items...)
+
+			return nil
+		})
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	if expiredAction != utils.ExpiredActionMark && expiredAction != utils.ExpiredActionDelete {
+		setupLog.Error(nil, "invalid --expired-exception-action, must be 'mark' or 'delete'", "value", expiredAction)
+		os.Exit(1)
+	}
+
+	requeueStrategy := utils.FixedRequeue{Interval: requeueInterval, JitterPercent: maxJitterPercent}
+	if err := requeueStrategy.Validate(); err != nil {
+		setupLog.Error(err, "invalid --requeue-interval or --max-jitter-percent")
+		os.Exit(1)
+	}
+	manifestBackoffStrategy := utils.ExponentialRequeue{Initial: manifestBackoff, Max: manifestMaxBackoff, JitterPercent: maxJitterPercent}
+	if err := manifestBackoffStrategy.Validate(); err != nil {
+		setupLog.Error(err, "invalid --manifest-missing-backoff or --manifest-missing-max-backoff")
+		os.Exit(1)
+	}
+
+	if historyBackend != "" && historyBackend != historyBackendFile && historyBackend != historyBackendConfigMap {
+		setupLog.Error(nil, "invalid --history-backend, must be 'file' or 'configmap'", "value", historyBackend)
+		os.Exit(1)
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	shutdownTracing, err := tracing.Setup(context.Background(), otlpEndpoint, otlpInsecure)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "unable to flush traces")
+		}
+	}()
+
+	if policyReportWorkers < 1 || automatedExceptionWorkers < 1 {
+		setupLog.Error(nil, "invalid --policyreport-workers or --automatedexception-workers, must be at least 1")
+		os.Exit(1)
+	}
+	if exceptionWriteQPS > 0 && exceptionWriteBurst < 1 {
+		setupLog.Error(nil, "invalid --exception-write-burst, must be at least 1", "value", exceptionWriteBurst)
+		os.Exit(1)
+	}
+	if enableSharding {
+		if !enableLeaderElection {
+			setupLog.Error(nil, "--enable-sharding requires --leader-elect, the other controllers must run on a single replica")
+			os.Exit(1)
+		}
+		if historyBackend == historyBackendFile {
+			setupLog.Error(nil, "--enable-sharding requires the 'configmap' --history-backend, the replicas don't share the history file")
+			os.Exit(1)
+		}
+		if shardLeaseNamespace == "" {
+			setupLog.Error(nil, "--enable-sharding requires --shard-lease-namespace")
+			os.Exit(1)
+		}
+		if shardIdentity == "" {
+			if shardIdentity, err = os.Hostname(); err != nil {
+				setupLog.Error(err, "unable to get the hostname, set --shard-identity")
+				os.Exit(1)
+			}
+		}
+		if shardLeaseDuration < 3*time.Second {
+			setupLog.Error(nil, "invalid --shard-lease-duration, must be at least 3s", "value", shardLeaseDuration)
+			os.Exit(1)
+		}
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                server.Options{BindAddress: metricsAddr},
 		HealthProbeBindAddress: probeAddr,
+		Cache:                  cache.Options{SyncPeriod: &resyncPeriod},
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "24b79667.giantswarm.io",
+		WebhookServer:          ctrlwebhook.NewServer(ctrlwebhook.Options{Port: webhookPort, CertDir: webhookCertDir}),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controller.PolicyReportReconciler{
-		Client:               mgr.GetClient(),
-		Scheme:               mgr.GetScheme(),
-		TargetWorkloads:      targetWorkloads,
-		TargetCategories:     targetCategories,
-		DestinationNamespace: destinationNamespace,
-		ExcludeNamespaces:    excludeNamespaces,
-		PolicyManifestCache:  policyManifestCache,
-		MaxJitterPercent:     maxJitterPercent,
-	}).SetupWithManager(mgr); err != nil {
+	// The writes of AutomatedExceptions share a token bucket
+	exceptionWriter := mgr.GetClient()
+	if exceptionWriteQPS > 0 {
+		exceptionWriter = controller.NewWriteLimitedClient(mgr.GetClient(), float32(exceptionWriteQPS), exceptionWriteBurst)
+	}
+
+	var dryRunRecorder *controller.DryRunRecorder
+	if dryRun {
+		setupLog.Info("running in dry-run mode, only the shard Leases and Events will be written")
+		dryRunRecorder = controller.NewDryRunRecorder()
+	}
+
+	// The limits only apply to the AutomatedExceptions actually created, the circuit breaker isn't written in dry-run mode
+	var creationLimits *controller.CreationLimits
+	if !dryRun && (maxExceptionsPerNamespace > 0 || maxExceptionsPerPolicy > 0 || maxCreationsPerMinute > 0) {
+		creationLimits = &controller.CreationLimits{
+			Client:                mgr.GetClient(),
+			Recorder:              mgr.GetEventRecorder("exception-recommender"),
+			MaxPerNamespace:       maxExceptionsPerNamespace,
+			MaxPerPolicy:          maxExceptionsPerPolicy,
+			MaxCreationsPerMinute: maxCreationsPerMinute,
+		}
+		if err = (&controller.CircuitBreakerReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ExceptionCircuitBreaker"),
+			Recorder: mgr.GetEventRecorder("exception-recommender"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ExceptionCircuitBreaker")
+			os.Exit(1)
+		}
+	}
+
+	var auditTrail *audit.Trail
+	if auditLog != "" || auditConfigMap != "" {
+		auditTrail = &audit.Trail{Sinks: make(map[string]audit.Sink)}
+		switch auditLog {
+		case "":
+		case "-":
+			auditTrail.Sinks["stdout"] = &audit.WriterSink{Writer: os.Stdout}
+		default:
+			auditTrail.Sinks["file"] = &audit.FileSink{Path: auditLog}
+		}
+		if auditConfigMap != "" {
+			namespace, prefix, found := strings.Cut(auditConfigMap, "/")
+			if !found || namespace == "" || prefix == "" {
+				setupLog.Error(nil, "invalid --audit-configmap, must be 'namespace/prefix'", "value", auditConfigMap)
+				os.Exit(1)
+			}
+			// The ConfigMaps are read directly, the cache would watch every ConfigMap of the cluster
+			auditClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+			if err != nil {
+				setupLog.Error(err, "unable to create audit client")
+				os.Exit(1)
+			}
+			configMapSink := &audit.ConfigMapSink{
+				Client:    auditClient,
+				Namespace: namespace,
+				Prefix:    prefix,
+				MaxDays:   auditConfigMapMaxDays,
+				Log:       ctrl.Log.WithName("audit"),
+			}
+			// The records are appended in batches by a single writer
+			if err = mgr.Add(configMapSink); err != nil {
+				setupLog.Error(err, "unable to set up audit ConfigMap writer")
+				os.Exit(1)
+			}
+			auditTrail.Sinks["configmap"] = configMapSink
+		}
+	}
+
+	// The Coordinator notifies the PolicyReportReconciler of the namespaces the replica takes over
+	var shardCoordinator *sharding.Coordinator
+	var shardChanges chan event.GenericEvent
+	if enableSharding {
+		shardChanges = make(chan event.GenericEvent, 1024)
+		shardCoordinator = &sharding.Coordinator{
+			Client:        mgr.GetClient(),
+			LeaseReader:   mgr.GetAPIReader(),
+			Namespace:     shardLeaseNamespace,
+			Group:         "exception-recommender-shard",
+			Identity:      shardIdentity,
+			LeaseDuration: shardLeaseDuration,
+			Changes:       shardChanges,
+			Log:           ctrl.Log.WithName("sharding"),
+		}
+		if err = mgr.Add(shardCoordinator); err != nil {
+			setupLog.Error(err, "unable to set up shard coordinator")
+			os.Exit(1)
+		}
+		// Every replica creates AutomatedExceptions for its own namespaces
+		if creationLimits != nil {
+			creationLimits.Replicas = shardCoordinator.Members
+		}
+	}
+
+	// The PolicyManifestReconciler notifies the PolicyReportReconciler of mode changes once its cache is updated
+	policyManifestChanges := make(chan event.GenericEvent, 1024)
+	policyReportReconciler := &controller.PolicyReportReconciler{
+		Client:                  exceptionWriter,
+		Scheme:                  mgr.GetScheme(),
+		Log:                     ctrl.Log.WithName("controllers").WithName("PolicyReport"),
+		TargetWorkloads:         targetWorkloads,
+		TargetCategories:        targetCategories,
+		DestinationNamespace:    destinationNamespace,
+		ExcludeNamespaces:       excludeNamespaces,
+		PolicyManifestCache:     policyManifestCache,
+		Requeue:                 requeueStrategy,
+		ExceptionTTL:            exceptionTTL,
+		DeletionGrace:           deletionGrace,
+		ProtectedPolicies:       protectedPolicies,
+		Recorder:                mgr.GetEventRecorder("exception-recommender"),
+		Limits:                  creationLimits,
+		DryRun:                  dryRunRecorder,
+		Audit:                   auditTrail,
+		ManifestBackoff:         controller.NewManifestBackoff(manifestBackoffStrategy),
+		PolicyManifestChanges:   policyManifestChanges,
+		MaxConcurrentReconciles: policyReportWorkers,
+	}
+	if shardCoordinator != nil {
+		policyReportReconciler.Shard = shardCoordinator
+		policyReportReconciler.ShardChanges = shardChanges
+	}
+	if err = policyReportReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PolicyReport")
 		os.Exit(1)
 	}
+	var historyStore history.Store
+	switch historyBackend {
+	case historyBackendFile:
+		historyStore = &history.FileStore{Path: historyFile}
+	case historyBackendConfigMap:
+		namespace, name, found := strings.Cut(historyConfigMap, "/")
+		if !found || namespace == "" || name == "" {
+			setupLog.Error(nil, "invalid --history-configmap, must be 'namespace/name'", "value", historyConfigMap)
+			os.Exit(1)
+		}
+		// The ConfigMap is read directly, the cache would watch every ConfigMap of the cluster
+		historyClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+		if err != nil {
+			setupLog.Error(err, "unable to create history client")
+			os.Exit(1)
+		}
+		historyStore = &history.ConfigMapStore{
+			Client:   historyClient,
+			Key:      types.NamespacedName{Namespace: namespace, Name: name},
+			Capacity: historyCapacity,
+		}
+	}
+	// The history is still served in dry-run mode, but no new point is recorded
+	if historyStore != nil && !dryRun {
+		if err = mgr.Add(&history.Recorder{
+			Client: mgr.GetClient(),
+			Store:  historyStore,
+			Log:    ctrl.Log.WithName("history"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up history recorder")
+			os.Exit(1)
+		}
+	}
+	if reportAddr != "0" {
+		reportMux := http.NewServeMux()
+		reportMux.Handle("/report", &controller.ComplianceReportHandler{Client: mgr.GetClient()})
+		if historyStore != nil {
+			reportMux.Handle("/history", &history.Handler{Store: historyStore})
+		}
+		reportMux.Handle("/explain", &controller.ExplainHandler{
+			Client:     mgr.GetClient(),
+			Reconciler: policyReportReconciler,
+		})
+		if dryRunRecorder != nil {
+			reportMux.Handle("/dry-run", dryRunRecorder)
+		}
+		// The report and the explanations list the workloads of every namespace, only authorized users may read them
+		authFilter, err := filters.WithAuthenticationAndAuthorization(mgr.GetConfig(), mgr.GetHTTPClient())
+		if err != nil {
+			setupLog.Error(err, "unable to set up compliance report authorization")
+			os.Exit(1)
+		}
+		reportHandler, err := authFilter(ctrl.Log.WithName("report"), reportMux)
+		if err != nil {
+			setupLog.Error(err, "unable to set up compliance report authorization")
+			os.Exit(1)
+		}
+		// The requests carry bearer tokens, they are only accepted over TLS
+		if reportCertDir == "" {
+			setupLog.Error(nil, "--report-bind-address requires --report-cert-dir")
+			os.Exit(1)
+		}
+		reportCertWatcher, err := certwatcher.New(filepath.Join(reportCertDir, "tls.crt"), filepath.Join(reportCertDir, "tls.key"))
+		if err != nil {
+			setupLog.Error(err, "unable to load compliance report certificate")
+			os.Exit(1)
+		}
+		if err = mgr.Add(reportCertWatcher); err != nil {
+			setupLog.Error(err, "unable to set up compliance report certificate watcher")
+			os.Exit(1)
+		}
+		reportListener, err := tls.Listen("tcp", reportAddr, &tls.Config{
+			GetCertificate: reportCertWatcher.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to listen on compliance report address")
+			os.Exit(1)
+		}
+		if err = mgr.Add(&manager.Server{
+			Name:     "report",
+			Server:   &http.Server{Handler: reportHandler, ReadHeaderTimeout: 10 * time.Second},
+			Listener: reportListener,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up compliance report endpoint")
+			os.Exit(1)
+		}
+	}
 	if err = (&controller.PolicyManifestReconciler{
 		Client:              mgr.GetClient(),
 		Scheme:              mgr.GetScheme(),
+		Log:                 ctrl.Log.WithName("controllers").WithName("PolicyManifest"),
 		PolicyManifestCache: policyManifestCache,
-		MaxJitterPercent:    maxJitterPercent,
+		ModeChanges:         policyManifestChanges,
+		Sharded:             enableSharding,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PolicyManifest")
 		os.Exit(1)
 	}
+	// The summary, readiness, approval and expiry controllers write their results, they are disabled in dry-run mode
+	if !dryRun {
+		if err = (&controller.SummaryReconciler{
+			Client:            mgr.GetClient(),
+			Scheme:            mgr.GetScheme(),
+			Log:               ctrl.Log.WithName("controllers").WithName("ExceptionRecommendationSummary"),
+			ExcludeNamespaces: excludeNamespaces,
+			TargetWorkloads:   targetWorkloads,
+			TargetCategories:  targetCategories,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ExceptionRecommendationSummary")
+			os.Exit(1)
+		}
+		if err = (&controller.EnforcementReadinessReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			Log:    ctrl.Log.WithName("controllers").WithName("EnforcementReadiness"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "EnforcementReadiness")
+			os.Exit(1)
+		}
+		if err = (&controller.AutomatedExceptionReconciler{
+			Client:                  exceptionWriter,
+			Scheme:                  mgr.GetScheme(),
+			Log:                     ctrl.Log.WithName("controllers").WithName("AutomatedException"),
+			Audit:                   auditTrail,
+			MaxConcurrentReconciles: automatedExceptionWorkers,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AutomatedException")
+			os.Exit(1)
+		}
+		if err = (&controller.ExpiryReconciler{
+			Client:                  exceptionWriter,
+			Scheme:                  mgr.GetScheme(),
+			Log:                     ctrl.Log.WithName("controllers").WithName("Expiry"),
+			Recorder:                mgr.GetEventRecorder("exception-recommender"),
+			ExceptionTTL:            exceptionTTL,
+			WarningWindow:           expiryWarningWindow,
+			ExpiredAction:           expiredAction,
+			Audit:                   auditTrail,
+			MaxConcurrentReconciles: automatedExceptionWorkers,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Expiry")
+			os.Exit(1)
+		}
+	}
+	if enableWebhook {
+		if err = (&webhook.AutomatedExceptionValidator{
+			AllowedUsers: webhookAllowedUsers,
+			Log:          ctrl.Log.WithName("webhooks").WithName("AutomatedException"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AutomatedException")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {